@@ -22,6 +22,9 @@ const _ = grpc.SupportPackageIsVersion9
 const (
 	Exchange_GetExchangeRates_FullMethodName      = "/exchange.Exchange/GetExchangeRates"
 	Exchange_GetExchangeRateForOne_FullMethodName = "/exchange.Exchange/GetExchangeRateForOne"
+	Exchange_GetExchangeRateAt_FullMethodName     = "/exchange.Exchange/GetExchangeRateAt"
+	Exchange_StreamExchangeRates_FullMethodName   = "/exchange.Exchange/StreamExchangeRates"
+	Exchange_ConvertBatch_FullMethodName          = "/exchange.Exchange/ConvertBatch"
 )
 
 // ExchangeClient is the client API for Exchange service.
@@ -30,6 +33,13 @@ const (
 type ExchangeClient interface {
 	GetExchangeRates(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ExchangeRatesResponse, error)
 	GetExchangeRateForOne(ctx context.Context, in *ExchangeRateRequest, opts ...grpc.CallOption) (*ExchangeRateResponse, error)
+	// Returns the rate that was in effect at the given time, NOT_FOUND if there was none.
+	GetExchangeRateAt(ctx context.Context, in *ExchangeRateAtRequest, opts ...grpc.CallOption) (*ExchangeRateResponse, error)
+	// Sends the current rates right away and then the full rate map every time it changes.
+	StreamExchangeRates(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExchangeRatesResponse], error)
+	// Converts every item with rates of one snapshot, so that all the items see the same rates.
+	// Results are in the order of the items.
+	ConvertBatch(ctx context.Context, in *ConvertBatchRequest, opts ...grpc.CallOption) (*ConvertBatchResponse, error)
 }
 
 type exchangeClient struct {
@@ -60,12 +70,58 @@ func (c *exchangeClient) GetExchangeRateForOne(ctx context.Context, in *Exchange
 	return out, nil
 }
 
+func (c *exchangeClient) GetExchangeRateAt(ctx context.Context, in *ExchangeRateAtRequest, opts ...grpc.CallOption) (*ExchangeRateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExchangeRateResponse)
+	err := c.cc.Invoke(ctx, Exchange_GetExchangeRateAt_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exchangeClient) StreamExchangeRates(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExchangeRatesResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Exchange_ServiceDesc.Streams[0], Exchange_StreamExchangeRates_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[emptypb.Empty, ExchangeRatesResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Exchange_StreamExchangeRatesClient = grpc.ServerStreamingClient[ExchangeRatesResponse]
+
+func (c *exchangeClient) ConvertBatch(ctx context.Context, in *ConvertBatchRequest, opts ...grpc.CallOption) (*ConvertBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConvertBatchResponse)
+	err := c.cc.Invoke(ctx, Exchange_ConvertBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ExchangeServer is the server API for Exchange service.
 // All implementations must embed UnimplementedExchangeServer
 // for forward compatibility.
 type ExchangeServer interface {
 	GetExchangeRates(context.Context, *emptypb.Empty) (*ExchangeRatesResponse, error)
 	GetExchangeRateForOne(context.Context, *ExchangeRateRequest) (*ExchangeRateResponse, error)
+	// Returns the rate that was in effect at the given time, NOT_FOUND if there was none.
+	GetExchangeRateAt(context.Context, *ExchangeRateAtRequest) (*ExchangeRateResponse, error)
+	// Sends the current rates right away and then the full rate map every time it changes.
+	StreamExchangeRates(*emptypb.Empty, grpc.ServerStreamingServer[ExchangeRatesResponse]) error
+	// Converts every item with rates of one snapshot, so that all the items see the same rates.
+	// Results are in the order of the items.
+	ConvertBatch(context.Context, *ConvertBatchRequest) (*ConvertBatchResponse, error)
 	mustEmbedUnimplementedExchangeServer()
 }
 
@@ -82,6 +138,15 @@ func (UnimplementedExchangeServer) GetExchangeRates(context.Context, *emptypb.Em
 func (UnimplementedExchangeServer) GetExchangeRateForOne(context.Context, *ExchangeRateRequest) (*ExchangeRateResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetExchangeRateForOne not implemented")
 }
+func (UnimplementedExchangeServer) GetExchangeRateAt(context.Context, *ExchangeRateAtRequest) (*ExchangeRateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetExchangeRateAt not implemented")
+}
+func (UnimplementedExchangeServer) StreamExchangeRates(*emptypb.Empty, grpc.ServerStreamingServer[ExchangeRatesResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamExchangeRates not implemented")
+}
+func (UnimplementedExchangeServer) ConvertBatch(context.Context, *ConvertBatchRequest) (*ConvertBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConvertBatch not implemented")
+}
 func (UnimplementedExchangeServer) mustEmbedUnimplementedExchangeServer() {}
 func (UnimplementedExchangeServer) testEmbeddedByValue()                  {}
 
@@ -139,6 +204,53 @@ func _Exchange_GetExchangeRateForOne_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Exchange_GetExchangeRateAt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExchangeRateAtRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExchangeServer).GetExchangeRateAt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Exchange_GetExchangeRateAt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExchangeServer).GetExchangeRateAt(ctx, req.(*ExchangeRateAtRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Exchange_StreamExchangeRates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ExchangeServer).StreamExchangeRates(m, &grpc.GenericServerStream[emptypb.Empty, ExchangeRatesResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Exchange_StreamExchangeRatesServer = grpc.ServerStreamingServer[ExchangeRatesResponse]
+
+func _Exchange_ConvertBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConvertBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExchangeServer).ConvertBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Exchange_ConvertBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExchangeServer).ConvertBatch(ctx, req.(*ConvertBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Exchange_ServiceDesc is the grpc.ServiceDesc for Exchange service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -154,6 +266,248 @@ var Exchange_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetExchangeRateForOne",
 			Handler:    _Exchange_GetExchangeRateForOne_Handler,
 		},
+		{
+			MethodName: "GetExchangeRateAt",
+			Handler:    _Exchange_GetExchangeRateAt_Handler,
+		},
+		{
+			MethodName: "ConvertBatch",
+			Handler:    _Exchange_ConvertBatch_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamExchangeRates",
+			Handler:       _Exchange_StreamExchangeRates_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/proto/exchange.proto",
+}
+
+const (
+	ExchangeAdmin_SetRate_FullMethodName            = "/exchange.ExchangeAdmin/SetRate"
+	ExchangeAdmin_UploadRates_FullMethodName        = "/exchange.ExchangeAdmin/UploadRates"
+	ExchangeAdmin_DeactivateCurrency_FullMethodName = "/exchange.ExchangeAdmin/DeactivateCurrency"
+	ExchangeAdmin_ActivateCurrency_FullMethodName   = "/exchange.ExchangeAdmin/ActivateCurrency"
+)
+
+// ExchangeAdminClient is the client API for ExchangeAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Changes rates. Every call must carry "authorization: Bearer <admin token>" metadata.
+type ExchangeAdminClient interface {
+	// Creates or updates the rate of one currency against the base currency.
+	SetRate(ctx context.Context, in *SetRateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Creates or updates several rates at once, either all of them or none.
+	UploadRates(ctx context.Context, in *UploadRatesRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Disables a currency, so that it can no longer be exchanged. Its rate is kept.
+	DeactivateCurrency(ctx context.Context, in *DeactivateCurrencyRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Enables a deactivated currency again with the rate it had.
+	ActivateCurrency(ctx context.Context, in *ActivateCurrencyRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type exchangeAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewExchangeAdminClient(cc grpc.ClientConnInterface) ExchangeAdminClient {
+	return &exchangeAdminClient{cc}
+}
+
+func (c *exchangeAdminClient) SetRate(ctx context.Context, in *SetRateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ExchangeAdmin_SetRate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exchangeAdminClient) UploadRates(ctx context.Context, in *UploadRatesRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ExchangeAdmin_UploadRates_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exchangeAdminClient) DeactivateCurrency(ctx context.Context, in *DeactivateCurrencyRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ExchangeAdmin_DeactivateCurrency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exchangeAdminClient) ActivateCurrency(ctx context.Context, in *ActivateCurrencyRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ExchangeAdmin_ActivateCurrency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ExchangeAdminServer is the server API for ExchangeAdmin service.
+// All implementations must embed UnimplementedExchangeAdminServer
+// for forward compatibility.
+//
+// Changes rates. Every call must carry "authorization: Bearer <admin token>" metadata.
+type ExchangeAdminServer interface {
+	// Creates or updates the rate of one currency against the base currency.
+	SetRate(context.Context, *SetRateRequest) (*emptypb.Empty, error)
+	// Creates or updates several rates at once, either all of them or none.
+	UploadRates(context.Context, *UploadRatesRequest) (*emptypb.Empty, error)
+	// Disables a currency, so that it can no longer be exchanged. Its rate is kept.
+	DeactivateCurrency(context.Context, *DeactivateCurrencyRequest) (*emptypb.Empty, error)
+	// Enables a deactivated currency again with the rate it had.
+	ActivateCurrency(context.Context, *ActivateCurrencyRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedExchangeAdminServer()
+}
+
+// UnimplementedExchangeAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedExchangeAdminServer struct{}
+
+func (UnimplementedExchangeAdminServer) SetRate(context.Context, *SetRateRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRate not implemented")
+}
+func (UnimplementedExchangeAdminServer) UploadRates(context.Context, *UploadRatesRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UploadRates not implemented")
+}
+func (UnimplementedExchangeAdminServer) DeactivateCurrency(context.Context, *DeactivateCurrencyRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeactivateCurrency not implemented")
+}
+func (UnimplementedExchangeAdminServer) ActivateCurrency(context.Context, *ActivateCurrencyRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ActivateCurrency not implemented")
+}
+func (UnimplementedExchangeAdminServer) mustEmbedUnimplementedExchangeAdminServer() {}
+func (UnimplementedExchangeAdminServer) testEmbeddedByValue()                       {}
+
+// UnsafeExchangeAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ExchangeAdminServer will
+// result in compilation errors.
+type UnsafeExchangeAdminServer interface {
+	mustEmbedUnimplementedExchangeAdminServer()
+}
+
+func RegisterExchangeAdminServer(s grpc.ServiceRegistrar, srv ExchangeAdminServer) {
+	// If the following call pancis, it indicates UnimplementedExchangeAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ExchangeAdmin_ServiceDesc, srv)
+}
+
+func _ExchangeAdmin_SetRate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExchangeAdminServer).SetRate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExchangeAdmin_SetRate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExchangeAdminServer).SetRate(ctx, req.(*SetRateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExchangeAdmin_UploadRates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UploadRatesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExchangeAdminServer).UploadRates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExchangeAdmin_UploadRates_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExchangeAdminServer).UploadRates(ctx, req.(*UploadRatesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExchangeAdmin_DeactivateCurrency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeactivateCurrencyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExchangeAdminServer).DeactivateCurrency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExchangeAdmin_DeactivateCurrency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExchangeAdminServer).DeactivateCurrency(ctx, req.(*DeactivateCurrencyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExchangeAdmin_ActivateCurrency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ActivateCurrencyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExchangeAdminServer).ActivateCurrency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExchangeAdmin_ActivateCurrency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExchangeAdminServer).ActivateCurrency(ctx, req.(*ActivateCurrencyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ExchangeAdmin_ServiceDesc is the grpc.ServiceDesc for ExchangeAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ExchangeAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "exchange.ExchangeAdmin",
+	HandlerType: (*ExchangeAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SetRate",
+			Handler:    _ExchangeAdmin_SetRate_Handler,
+		},
+		{
+			MethodName: "UploadRates",
+			Handler:    _ExchangeAdmin_UploadRates_Handler,
+		},
+		{
+			MethodName: "DeactivateCurrency",
+			Handler:    _ExchangeAdmin_DeactivateCurrency_Handler,
+		},
+		{
+			MethodName: "ActivateCurrency",
+			Handler:    _ExchangeAdmin_ActivateCurrency_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/proto/exchange.proto",
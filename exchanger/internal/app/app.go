@@ -23,7 +23,9 @@ import (
 	"sync"
 	"test-task/api/gen/grpc/exchange"
 	"test-task/exchanger/internal/config"
+	"test-task/exchanger/internal/providers"
 	"test-task/exchanger/internal/services"
+	"test-task/exchanger/internal/storage/memory"
 	"test-task/exchanger/internal/storage/postgres"
 	mygrpc "test-task/exchanger/internal/transport/grpc"
 	"time"
@@ -35,10 +37,11 @@ type shutdownTask struct {
 }
 
 type App struct {
-	cfg       *config.Config
-	server    *grpc.Server
-	listener  net.Listener
-	shutdowns []shutdownTask
+	cfg          *config.Config
+	server       *grpc.Server
+	listener     net.Listener
+	stopWatching context.CancelFunc
+	shutdowns    []shutdownTask
 }
 
 func New() (*App, error) {
@@ -53,15 +56,19 @@ func New() (*App, error) {
 
 	initLogger(cfg)
 
-	storage, connector, err := InitDB(cfg)
+	storage, storageShutdown, err := InitStorage(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize database: %w", err)
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
-	shutdowns = append(shutdowns, shutdownTask{
-		name:     "db connection",
-		shutdown: connector.Close,
-	})
+	if storageShutdown != nil {
+		shutdowns = append(shutdowns, *storageShutdown)
+	}
+
+	currencies := services.NewCurrencyService(storage, cfg.CurrenciesRefresh)
+	if err = currencies.Load(context.Background()); err != nil {
+		return nil, err
+	}
 
 	tracer, err := initTracer(cfg)
 	if err != nil {
@@ -73,13 +80,20 @@ func New() (*App, error) {
 		shutdown: tracer.Shutdown,
 	})
 
-	server := grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler()))
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.UnaryInterceptor(mygrpc.AdminAuthInterceptor(cfg.AdminToken)),
+	)
 	reflection.Register(server)
 
 	exchangeService := services.NewExchangeService(storage)
 	exchangeServer := mygrpc.NewExchangeServer(exchangeService)
 	exchange.RegisterExchangeServer(server, exchangeServer)
 
+	adminService := services.NewAdminService(storage, currencies)
+	adminServer := mygrpc.NewAdminServer(adminService)
+	exchange.RegisterExchangeAdminServer(server, adminServer)
+
 	healthServer := health.NewServer()
 	healthServer.SetServingStatus("", healthgrpc.HealthCheckResponse_SERVING)
 	healthgrpc.RegisterHealthServer(server, healthServer)
@@ -93,11 +107,28 @@ func New() (*App, error) {
 		shutdown: consulShutdown,
 	})
 
+	var importer *services.RateImporter
+	if cfg.RateProvider.Kind != "" {
+		provider, err := providers.New(cfg.RateProvider.Kind, cfg.RateProvider.Source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create rate provider: %w", err)
+		}
+		importer = services.NewRateImporter(provider, storage, cfg.RateProvider.Interval)
+	}
+
 	listener, err := net.Listen("tcp", ":"+cfg.Port)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create port listener: %w", err)
 	}
-	return &App{cfg: cfg, server: server, listener: listener, shutdowns: shutdowns}, nil
+
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	go exchangeService.WatchRates(watchCtx)
+	go currencies.Watch(watchCtx)
+	if importer != nil {
+		go importer.Run(watchCtx)
+	}
+
+	return &App{cfg: cfg, server: server, listener: listener, stopWatching: stopWatching, shutdowns: shutdowns}, nil
 }
 
 func (a *App) Run() {
@@ -111,6 +142,8 @@ func (a *App) Stop(ctx context.Context) {
 
 	slog.Info("shutting down gracefully...")
 
+	// rate streams never end on their own, so they are closed before waiting for running calls
+	a.stopWatching()
 	a.server.GracefulStop()
 	slog.Info("grpc server gracefully stopped")
 
@@ -144,6 +177,32 @@ func initLogger(cfg *config.Config) {
 	slog.SetDefault(logger)
 }
 
+// Storage is everything the services need from a storage.
+type Storage interface {
+	services.WriteStorage
+	services.CurrenciesStorage
+	services.ImportStorage
+}
+
+// InitStorage creates the storage chosen by the config. Shutting down is only needed for some of them.
+func InitStorage(cfg *config.Config) (Storage, *shutdownTask, error) {
+
+	if cfg.Storage == config.MemoryStorage {
+		storage, err := memory.Load(cfg.StorageSeed)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to initialize memory storage: %w", err)
+		}
+		slog.Info("using memory storage", "seed", cfg.StorageSeed)
+		return storage, nil, nil
+	}
+
+	storage, connector, err := InitDB(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	return storage, &shutdownTask{name: "db connection", shutdown: connector.Close}, nil
+}
+
 func InitDB(cfg *config.Config) (*postgres.Storage, *postgres.Connector, error) {
 	connector, err := postgres.NewConnector(context.Background(), cfg.DbUrl)
 	if err != nil {
@@ -155,12 +214,7 @@ func InitDB(cfg *config.Config) (*postgres.Storage, *postgres.Connector, error)
 		return nil, nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	storage, err := postgres.NewStorage(connector.Pool)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to initialize storage: %w", err)
-	}
-
-	return storage, connector, nil
+	return postgres.NewStorage(connector.Pool), connector, nil
 }
 
 func initTracer(cfg *config.Config) (*trace.TracerProvider, error) {
@@ -6,6 +6,8 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/joho/godotenv"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Environment string
@@ -15,14 +17,26 @@ const (
 	Development Environment = "development"
 )
 
+type StorageType string
+
+const (
+	PostgresStorage StorageType = "postgres"
+	MemoryStorage   StorageType = "memory"
+)
+
 type Config struct {
-	Env            Environment
-	ServiceName    string `validate:"required"`
-	Port           string `validate:"required"`
-	DbUrl          string `validate:"required"`
-	MigrationsPath string `validate:"required"`
-	OtelEndpoint   string `validate:"required"`
-	ConsulAddress  string
+	Env               Environment
+	ServiceName       string        `validate:"required"`
+	Port              string        `validate:"required"`
+	Storage           StorageType   `validate:"oneof=postgres memory"`
+	StorageSeed       string        `validate:"required_if=Storage memory"`
+	DbUrl             string        `validate:"required_if=Storage postgres"`
+	MigrationsPath    string        `validate:"required_if=Storage postgres"`
+	OtelEndpoint      string        `validate:"required"`
+	AdminToken        string        `validate:"required"`
+	CurrenciesRefresh time.Duration `validate:"required,gt=0"`
+	RateProvider      RateProviderConfig
+	ConsulAddress     string
 }
 
 var flagSet = false
@@ -35,18 +49,33 @@ func Get() (*Config, error) {
 		}
 	}
 
+	currenciesRefresh, err := strconv.Atoi(os.Getenv("CURRENCIES_REFRESH_INTERVAL"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert currencies refresh interval: %w", err)
+	}
+
+	rateProvider, err := getRateProvider()
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := Config{
-		Env:            getEnvironment(),
-		ServiceName:    os.Getenv("SERVICE_NAME"),
-		Port:           os.Getenv("PORT"),
-		DbUrl:          os.Getenv("DB_URL"),
-		MigrationsPath: os.Getenv("MIGRATIONS_PATH"),
-		OtelEndpoint:   os.Getenv("OTEL_ENDPOINT"),
-		ConsulAddress:  os.Getenv("CONSUL_ADDRESS"),
+		Env:               getEnvironment(),
+		ServiceName:       os.Getenv("SERVICE_NAME"),
+		Port:              os.Getenv("PORT"),
+		Storage:           getStorageType(),
+		StorageSeed:       os.Getenv("STORAGE_SEED"),
+		DbUrl:             os.Getenv("DB_URL"),
+		MigrationsPath:    os.Getenv("MIGRATIONS_PATH"),
+		OtelEndpoint:      os.Getenv("OTEL_ENDPOINT"),
+		AdminToken:        os.Getenv("EXCHANGER_ADMIN_TOKEN"),
+		CurrenciesRefresh: time.Duration(currenciesRefresh) * time.Second,
+		RateProvider:      rateProvider,
+		ConsulAddress:     os.Getenv("CONSUL_ADDRESS"),
 	}
 
 	validate := validator.New()
-	if err := validate.Struct(cfg); err != nil {
+	if err = validate.Struct(cfg); err != nil {
 		return nil, fmt.Errorf("failed to validate config: %w", err)
 	}
 	return &cfg, nil
@@ -78,3 +107,11 @@ func getEnvironment() Environment {
 		return Development
 	}
 }
+
+func getStorageType() StorageType {
+	storage := StorageType(os.Getenv("STORAGE"))
+	if storage == "" {
+		return PostgresStorage
+	}
+	return storage
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"test-task/exchanger/internal/providers"
+	"time"
+)
+
+// RateProviderConfig configures importing of rates. An empty Kind disables it.
+type RateProviderConfig struct {
+	Kind     providers.Kind `validate:"omitempty,oneof=csv ecb json"`
+	Source   string         `validate:"required_with=Kind"`
+	Interval time.Duration
+}
+
+func getRateProvider() (RateProviderConfig, error) {
+
+	cfg := RateProviderConfig{
+		Kind:   providers.Kind(os.Getenv("RATE_PROVIDER")),
+		Source: os.Getenv("RATE_PROVIDER_SOURCE"),
+	}
+	if cfg.Kind == "" {
+		return cfg, nil
+	}
+
+	interval, err := strconv.Atoi(os.Getenv("RATE_PROVIDER_INTERVAL"))
+	if err != nil {
+		return cfg, fmt.Errorf("failed to convert rate provider interval: %w", err)
+	}
+	if interval <= 0 {
+		return cfg, fmt.Errorf("rate provider interval must be positive")
+	}
+
+	cfg.Interval = time.Duration(interval) * time.Second
+	return cfg, nil
+}
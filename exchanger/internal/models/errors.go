@@ -0,0 +1,9 @@
+package models
+
+import "errors"
+
+var RateNotFound = errors.New("rate not found")
+var CurrencyNotFound = errors.New("currency not found")
+var InvalidRate = errors.New("invalid rate")
+var BaseCurrencyRate = errors.New("only the base currency has rate 1 and it cannot be changed")
+var BaseCurrencyDeactivation = errors.New("base currency cannot be deactivated")
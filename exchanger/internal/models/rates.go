@@ -0,0 +1,75 @@
+package models
+
+import (
+	"fmt"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// SourceRates are the rates fetched from an external source. They are relative to the base currency
+// of the source, which is not necessarily ours.
+type SourceRates struct {
+	Base      Currency
+	Rates     map[Currency]decimal.Decimal
+	FetchedAt time.Time
+	// EffectiveAt is when the rates took effect according to the source, zero if it doesn't tell.
+	EffectiveAt time.Time
+}
+
+// RatesVersion identifies a state of the rates. Version grows with every change of the rates
+// and UpdatedAt is the time the state took effect. Historical states have no Version.
+type RatesVersion struct {
+	Base      Currency
+	Version   int64
+	UpdatedAt time.Time
+}
+
+// RateSnapshot is a set of rates relative to Base that were read at once, so they belong to the same version.
+type RateSnapshot struct {
+	RatesVersion
+	Rates map[Currency]decimal.Decimal
+}
+
+// Rate is the rate between two currencies along with the state of the rates it was computed from.
+type Rate struct {
+	RatesVersion
+	Value decimal.Decimal
+}
+
+// Rate returns the rate between two currencies of the snapshot.
+func (s *RateSnapshot) Rate(from Currency, to Currency) (decimal.Decimal, error) {
+
+	if from == to {
+		return decimal.NewFromInt(1), nil
+	}
+
+	fromRate, ok := s.Rates[from]
+	if !ok || fromRate.IsZero() {
+		return decimal.Zero, fmt.Errorf("%w: %s", RateNotFound, from)
+	}
+
+	toRate, ok := s.Rates[to]
+	if !ok || toRate.IsZero() {
+		return decimal.Zero, fmt.Errorf("%w: %s", RateNotFound, to)
+	}
+
+	if from == s.Base {
+		return toRate, nil
+	}
+
+	if to == s.Base {
+		return decimal.NewFromInt(1).Div(fromRate), nil
+	}
+
+	return toRate.Div(fromRate), nil
+}
+
+// Conversion is an amount converted between two currencies. ConvertedAmount is rounded down
+// to the scale of To.
+type Conversion struct {
+	From            Currency
+	To              Currency
+	Amount          decimal.Decimal
+	ConvertedAmount decimal.Decimal
+	Rate            decimal.Decimal
+}
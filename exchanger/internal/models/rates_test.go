@@ -0,0 +1,44 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_Rate_ShouldBeInverseOfReverseRate(t *testing.T) {
+
+	snapshot := RateSnapshot{
+		RatesVersion: RatesVersion{Base: USD},
+		Rates: map[Currency]decimal.Decimal{
+			USD: decimal.NewFromInt(1),
+			EUR: decimal.RequireFromString("0.8"),
+			RUB: decimal.RequireFromString("80"),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		from, to Currency
+		expected string
+	}{
+		{name: "base to currency", from: USD, to: RUB, expected: "80"},
+		{name: "currency to base", from: EUR, to: USD, expected: "1.25"},
+		{name: "cross", from: EUR, to: RUB, expected: "100"},
+		{name: "reverse cross", from: RUB, to: EUR, expected: "0.01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			rate, err := snapshot.Rate(tt.from, tt.to)
+			require.NoError(t, err)
+			assert.True(t, decimal.RequireFromString(tt.expected).Equal(rate), "got %s", rate)
+
+			reverse, err := snapshot.Rate(tt.to, tt.from)
+			require.NoError(t, err)
+			assert.True(t, rate.Mul(reverse).Equal(decimal.NewFromInt(1)), "%s * %s != 1", rate, reverse)
+		})
+	}
+}
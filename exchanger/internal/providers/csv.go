@@ -0,0 +1,66 @@
+package providers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"io"
+	"strings"
+	"test-task/exchanger/internal/models"
+	"time"
+)
+
+// CSVProvider reads "currency,rate" rows, optionally under a header. The base currency of the file
+// is the one with the rate of 1.
+type CSVProvider struct {
+	src location
+}
+
+func (p *CSVProvider) Name() string {
+	return string(CSV)
+}
+
+func (p *CSVProvider) Fetch(ctx context.Context) (*models.SourceRates, error) {
+
+	body, err := p.src.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	res := &models.SourceRates{Rates: make(map[models.Currency]decimal.Decimal), FetchedAt: time.Now()}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read csv: %w", err)
+		}
+		if line == 1 && strings.EqualFold(record[0], "currency") {
+			continue
+		}
+
+		rate, err := decimal.NewFromString(strings.TrimSpace(record[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate on line %d: %w", line, err)
+		}
+
+		currency := models.Currency(strings.ToUpper(strings.TrimSpace(record[0])))
+		res.Rates[currency] = rate
+		if rate.Equal(decimal.NewFromInt(1)) {
+			res.Base = currency
+		}
+	}
+
+	if res.Base == "" {
+		return nil, fmt.Errorf("no base currency with the rate of 1 in csv")
+	}
+	return res, nil
+}
@@ -0,0 +1,72 @@
+package providers
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"test-task/exchanger/internal/models"
+	"time"
+)
+
+// ECBProvider reads the daily reference rates of the European Central Bank
+// (eurofxref-daily.xml), which are quoted against EUR.
+type ECBProvider struct {
+	src location
+}
+
+type ecbEnvelope struct {
+	Cube struct {
+		Cube struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+func (p *ECBProvider) Name() string {
+	return string(ECB)
+}
+
+func (p *ECBProvider) Fetch(ctx context.Context) (*models.SourceRates, error) {
+
+	body, err := p.src.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var envelope ecbEnvelope
+	if err = xml.NewDecoder(body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode ecb rates: %w", err)
+	}
+
+	cube := envelope.Cube.Cube
+	if len(cube.Rates) == 0 {
+		return nil, fmt.Errorf("no rates in ecb response")
+	}
+
+	// the reference rates are published for the day in the time attribute, e.g. 2025-01-02
+	effectiveAt, err := time.Parse(time.DateOnly, cube.Time)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ecb reference date %q: %w", cube.Time, err)
+	}
+
+	res := &models.SourceRates{
+		Base:        models.EUR,
+		Rates:       map[models.Currency]decimal.Decimal{models.EUR: decimal.NewFromInt(1)},
+		FetchedAt:   time.Now(),
+		EffectiveAt: effectiveAt,
+	}
+	for _, r := range cube.Rates {
+		rate, err := decimal.NewFromString(r.Rate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ecb rate of %s: %w", r.Currency, err)
+		}
+		res.Rates[models.Currency(r.Currency)] = rate
+	}
+	return res, nil
+}
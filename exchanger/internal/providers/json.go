@@ -0,0 +1,51 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"test-task/exchanger/internal/models"
+	"time"
+)
+
+// JSONProvider reads {"base": "USD", "rates": {"EUR": "0.85", ...}}. Rates may be strings or numbers.
+type JSONProvider struct {
+	src location
+}
+
+type jsonRates struct {
+	Base  string                     `json:"base"`
+	Rates map[string]decimal.Decimal `json:"rates"`
+}
+
+func (p *JSONProvider) Name() string {
+	return string(JSON)
+}
+
+func (p *JSONProvider) Fetch(ctx context.Context) (*models.SourceRates, error) {
+
+	body, err := p.src.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var data jsonRates
+	if err = json.NewDecoder(body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode json rates: %w", err)
+	}
+	if data.Base == "" {
+		return nil, fmt.Errorf("no base currency in json rates")
+	}
+
+	res := &models.SourceRates{
+		Base:      models.Currency(data.Base),
+		Rates:     map[models.Currency]decimal.Decimal{models.Currency(data.Base): decimal.NewFromInt(1)},
+		FetchedAt: time.Now(),
+	}
+	for currency, rate := range data.Rates {
+		res.Rates[models.Currency(currency)] = rate
+	}
+	return res, nil
+}
@@ -0,0 +1,71 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"test-task/exchanger/internal/models"
+	"time"
+)
+
+type Kind string
+
+const (
+	CSV  Kind = "csv"
+	ECB  Kind = "ecb"
+	JSON Kind = "json"
+)
+
+type Provider interface {
+	Name() string
+	Fetch(ctx context.Context) (*models.SourceRates, error)
+}
+
+const requestTimeout = 30 * time.Second
+
+// New returns the provider of the given kind. The source is either a file path or an http(s) URL.
+func New(kind Kind, source string) (Provider, error) {
+
+	src := location{path: source, client: &http.Client{Timeout: requestTimeout}}
+
+	switch kind {
+	case CSV:
+		return &CSVProvider{src: src}, nil
+	case ECB:
+		return &ECBProvider{src: src}, nil
+	case JSON:
+		return &JSONProvider{src: src}, nil
+	default:
+		return nil, fmt.Errorf("unknown rate provider: %q", kind)
+	}
+}
+
+type location struct {
+	path   string
+	client *http.Client
+}
+
+func (l location) open(ctx context.Context) (io.ReadCloser, error) {
+
+	if !strings.HasPrefix(l.path, "http://") && !strings.HasPrefix(l.path, "https://") {
+		return os.Open(l.path)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", l.path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to fetch %s: unexpected status %s", l.path, resp.Status)
+	}
+	return resp.Body, nil
+}
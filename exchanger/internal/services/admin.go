@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"test-task/exchanger/internal/models"
+)
+
+// WriteStorage is the write side of Storage used by the admin API.
+type WriteStorage interface {
+	Storage
+	GetBaseCurrency(ctx context.Context) (models.Currency, error)
+	SetRate(ctx context.Context, currency models.Currency, rate decimal.Decimal) error
+	SetRates(ctx context.Context, rates map[models.Currency]decimal.Decimal) error
+	SetCurrencyEnabled(ctx context.Context, currency models.Currency, enabled bool) error
+}
+
+// CurrencyLoader reloads the currency registry.
+type CurrencyLoader interface {
+	Load(ctx context.Context) error
+}
+
+type AdminService struct {
+	storage    WriteStorage
+	currencies CurrencyLoader
+}
+
+func NewAdminService(storage WriteStorage, currencies CurrencyLoader) *AdminService {
+	return &AdminService{storage: storage, currencies: currencies}
+}
+
+func (a *AdminService) SetRate(ctx context.Context, currency models.Currency, rate decimal.Decimal) error {
+
+	err := a.validateRates(ctx, map[models.Currency]decimal.Decimal{currency: rate})
+	if err != nil {
+		return err
+	}
+	return a.storage.SetRate(ctx, currency, rate)
+}
+
+func (a *AdminService) SetRates(ctx context.Context, rates map[models.Currency]decimal.Decimal) error {
+
+	err := a.validateRates(ctx, rates)
+	if err != nil {
+		return err
+	}
+	return a.storage.SetRates(ctx, rates)
+}
+
+// DeactivateCurrency disables the currency, so that it can no longer be exchanged. Its rate is kept,
+// so the currency can be activated again.
+func (a *AdminService) DeactivateCurrency(ctx context.Context, currency models.Currency) error {
+
+	base, err := a.storage.GetBaseCurrency(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get base currency: %w", err)
+	}
+
+	if currency == base {
+		return models.BaseCurrencyDeactivation
+	}
+
+	return a.setCurrencyEnabled(ctx, currency, false)
+}
+
+// ActivateCurrency enables a deactivated currency again with the rate it had.
+func (a *AdminService) ActivateCurrency(ctx context.Context, currency models.Currency) error {
+	return a.setCurrencyEnabled(ctx, currency, true)
+}
+
+// setCurrencyEnabled changes the currency and reloads the registry right away instead of waiting
+// for the next refresh, so the change is visible to the requests that follow.
+func (a *AdminService) setCurrencyEnabled(ctx context.Context, currency models.Currency, enabled bool) error {
+
+	if err := a.storage.SetCurrencyEnabled(ctx, currency, enabled); err != nil {
+		return err
+	}
+	return a.currencies.Load(ctx)
+}
+
+// validateRates checks that the rates are positive and keep the base currency at 1. The base currency
+// is recognised by its rate of 1, so no other currency may get it.
+func (a *AdminService) validateRates(ctx context.Context, rates map[models.Currency]decimal.Decimal) error {
+
+	base, err := a.storage.GetBaseCurrency(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get base currency: %w", err)
+	}
+
+	one := decimal.NewFromInt(1)
+	for currency, rate := range rates {
+		if !rate.IsPositive() {
+			return fmt.Errorf("%w: %s must be positive", models.InvalidRate, currency)
+		}
+		if (currency == base) != rate.Equal(one) {
+			return models.BaseCurrencyRate
+		}
+	}
+	return nil
+}
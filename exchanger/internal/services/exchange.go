@@ -2,26 +2,135 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"sync"
 	"test-task/exchanger/internal/models"
+	"time"
 )
 
 type Storage interface {
-	GetRate(ctx context.Context, from models.Currency, to models.Currency) (float64, error)
-	GetRates(ctx context.Context) (map[models.Currency]float64, error)
+	GetRate(ctx context.Context, from models.Currency, to models.Currency) (*models.Rate, error)
+	GetRates(ctx context.Context) (*models.RateSnapshot, error)
+	GetRateAt(ctx context.Context, from models.Currency, to models.Currency, at time.Time) (*models.Rate, error)
+	ListenRateChanges(ctx context.Context, onChange func()) error
 }
 
+const listenRetryDelay = 5 * time.Second
+
 type ExchangeService struct {
 	storage Storage
+
+	mu          sync.Mutex
+	subscribers map[chan struct{}]struct{}
+	stopped     bool
 }
 
 func NewExchangeService(storage Storage) *ExchangeService {
-	return &ExchangeService{storage: storage}
+	return &ExchangeService{storage: storage, subscribers: make(map[chan struct{}]struct{})}
 }
 
-func (e *ExchangeService) GetRate(ctx context.Context, from models.Currency, to models.Currency) (float64, error) {
+func (e *ExchangeService) GetRate(ctx context.Context, from models.Currency, to models.Currency) (*models.Rate, error) {
 	return e.storage.GetRate(ctx, from, to)
 }
 
-func (e *ExchangeService) GetRates(ctx context.Context) (map[models.Currency]float64, error) {
+func (e *ExchangeService) GetRates(ctx context.Context) (*models.RateSnapshot, error) {
 	return e.storage.GetRates(ctx)
 }
+
+func (e *ExchangeService) GetRateAt(ctx context.Context, from models.Currency, to models.Currency,
+	at time.Time) (*models.Rate, error) {
+	return e.storage.GetRateAt(ctx, from, to, at)
+}
+
+// Convert fills in the rate and the converted amount of every conversion. All of them are computed
+// from one snapshot of the rates.
+func (e *ExchangeService) Convert(ctx context.Context, conversions []models.Conversion) ([]models.Conversion, error) {
+
+	snapshot, err := e.storage.GetRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]models.Conversion, len(conversions))
+	for i, conversion := range conversions {
+		rate, err := snapshot.Rate(conversion.From, conversion.To)
+		if err != nil {
+			return nil, fmt.Errorf("conversion %d: %w", i, err)
+		}
+
+		conversion.Rate = rate
+		conversion.ConvertedAmount = conversion.Amount.Mul(rate).RoundDown(conversion.To.Scale())
+		res[i] = conversion
+	}
+	return res, nil
+}
+
+// WatchRates notifies the subscribers about rate changes until ctx is done, reconnecting on failures.
+// Then it closes all the subscriptions.
+func (e *ExchangeService) WatchRates(ctx context.Context) {
+
+	defer e.stop()
+
+	for {
+		err := e.storage.ListenRateChanges(ctx, e.notify)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Error("failed to listen to rate changes", "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+// SubscribeRates returns a channel that receives a value after the rates change. Changes that happen
+// before the previous one is received are merged into one. The channel is closed when WatchRates stops.
+// The subscription must be cancelled when no longer needed.
+func (e *ExchangeService) SubscribeRates() (<-chan struct{}, func()) {
+
+	ch := make(chan struct{}, 1)
+
+	e.mu.Lock()
+	if e.stopped {
+		close(ch)
+	} else {
+		e.subscribers[ch] = struct{}{}
+	}
+	e.mu.Unlock()
+
+	cancel := func() {
+		e.mu.Lock()
+		delete(e.subscribers, ch)
+		e.mu.Unlock()
+	}
+	return ch, cancel
+}
+
+func (e *ExchangeService) notify() {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for ch := range e.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (e *ExchangeService) stop() {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for ch := range e.subscribers {
+		close(ch)
+	}
+	clear(e.subscribers)
+	e.stopped = true
+}
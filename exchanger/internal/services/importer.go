@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"log/slog"
+	"test-task/exchanger/internal/models"
+	"time"
+)
+
+type RateProvider interface {
+	Name() string
+	Fetch(ctx context.Context) (*models.SourceRates, error)
+}
+
+type ImportStorage interface {
+	GetBaseCurrency(ctx context.Context) (models.Currency, error)
+	ImportRates(ctx context.Context, rates map[models.Currency]decimal.Decimal, source string,
+		fetchedAt, effectiveAt time.Time) error
+}
+
+// rateScale is the number of decimal places the rebased rates are rounded to.
+const rateScale = 10
+
+// RateImporter periodically fetches rates from a provider and stores them relative to our base currency.
+type RateImporter struct {
+	provider RateProvider
+	storage  ImportStorage
+	interval time.Duration
+}
+
+func NewRateImporter(provider RateProvider, storage ImportStorage, interval time.Duration) *RateImporter {
+	return &RateImporter{provider: provider, storage: storage, interval: interval}
+}
+
+func (r *RateImporter) Import(ctx context.Context) error {
+
+	fetched, err := r.provider.Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch rates from %s: %w", r.provider.Name(), err)
+	}
+
+	base, err := r.storage.GetBaseCurrency(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get base currency: %w", err)
+	}
+
+	rates, err := normaliseRates(fetched, base)
+	if err != nil {
+		return fmt.Errorf("failed to normalise rates from %s: %w", r.provider.Name(), err)
+	}
+
+	// rates the source doesn't date take effect when they are fetched
+	effectiveAt := fetched.EffectiveAt
+	if effectiveAt.IsZero() {
+		effectiveAt = fetched.FetchedAt
+	}
+
+	return r.storage.ImportRates(ctx, rates, r.provider.Name(), fetched.FetchedAt, effectiveAt)
+}
+
+// Run imports the rates right away and then every interval until ctx is done.
+func (r *RateImporter) Run(ctx context.Context) {
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Import(ctx); err != nil {
+			slog.Error("failed to import rates", "provider", r.provider.Name(), "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// normaliseRates rebases the fetched rates onto base. Unknown currencies are skipped, as well as those
+// that would get the rate of 1, which is reserved for the base currency.
+func normaliseRates(fetched *models.SourceRates, base models.Currency) (map[models.Currency]decimal.Decimal, error) {
+
+	baseRate, ok := fetched.Rates[base]
+	if !ok || !baseRate.IsPositive() {
+		return nil, fmt.Errorf("%w: no rate of the base currency %s", models.InvalidRate, base)
+	}
+
+	one := decimal.NewFromInt(1)
+	res := map[models.Currency]decimal.Decimal{base: one}
+	for currency, rate := range fetched.Rates {
+		if currency == base {
+			continue
+		}
+		if !currency.IsKnown() {
+			slog.Debug("skipping rate of unknown currency", "currency", currency)
+			continue
+		}
+		if !rate.IsPositive() {
+			return nil, fmt.Errorf("%w: %s must be positive", models.InvalidRate, currency)
+		}
+
+		normalised := rate.DivRound(baseRate, rateScale)
+		if normalised.Equal(one) {
+			slog.Warn("skipping rate equal to the base one", "currency", currency)
+			continue
+		}
+		res[currency] = normalised
+	}
+	return res, nil
+}
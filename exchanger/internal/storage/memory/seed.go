@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+	"test-task/exchanger/internal/models"
+)
+
+// Seed is the initial content of the storage. Rates are relative to the base currency, which has the rate of 1.
+//
+//	currencies:
+//	  - {code: USD, name: US Dollar, scale: 2, enabled: true}
+//	rates:
+//	  USD: 1
+type Seed struct {
+	Currencies []SeedCurrency             `json:"currencies" yaml:"currencies"`
+	Rates      map[string]decimal.Decimal `json:"rates" yaml:"rates"`
+}
+
+type SeedCurrency struct {
+	Code    string `json:"code" yaml:"code"`
+	Name    string `json:"name" yaml:"name"`
+	Scale   int32  `json:"scale" yaml:"scale"`
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+}
+
+// Load creates a storage seeded from a YAML or JSON file, chosen by the file extension.
+func Load(path string) (*Storage, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed file: %w", err)
+	}
+
+	var seed Seed
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &seed)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &seed)
+	default:
+		return nil, fmt.Errorf("unsupported seed file format: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse seed file: %w", err)
+	}
+
+	return NewStorage(&seed)
+}
+
+func (s *Seed) validate() error {
+
+	if len(s.Currencies) == 0 {
+		return fmt.Errorf("no currencies in seed")
+	}
+
+	known := make(map[string]struct{}, len(s.Currencies))
+	for _, currency := range s.Currencies {
+		if currency.Code == "" {
+			return fmt.Errorf("currency without code in seed")
+		}
+		if currency.Scale < 0 || currency.Scale > 18 {
+			return fmt.Errorf("invalid scale of %s in seed", currency.Code)
+		}
+		known[currency.Code] = struct{}{}
+	}
+
+	bases := 0
+	for currency, rate := range s.Rates {
+		if _, ok := known[currency]; !ok {
+			return fmt.Errorf("rate of unknown currency %s in seed", currency)
+		}
+		if !rate.IsPositive() {
+			return fmt.Errorf("%w: %s must be positive", models.InvalidRate, currency)
+		}
+		if rate.Equal(decimal.NewFromInt(1)) {
+			bases++
+		}
+	}
+	if bases != 1 {
+		return fmt.Errorf("seed must have exactly one base currency with the rate of 1")
+	}
+	return nil
+}
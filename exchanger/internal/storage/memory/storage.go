@@ -0,0 +1,315 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"slices"
+	"sync"
+	"test-task/exchanger/internal/models"
+	"time"
+)
+
+// seedSource is recorded as the source of the seeded rates.
+const seedSource = "seed"
+
+// adminSource is recorded as the source of the rates set through the admin API.
+const adminSource = "admin"
+
+type rate struct {
+	value     decimal.Decimal
+	source    string
+	fetchedAt time.Time
+}
+
+type historyEntry struct {
+	currency  models.Currency
+	rate      decimal.Decimal
+	validFrom time.Time
+	validTo   time.Time // zero while the rate is current
+}
+
+// Storage keeps currencies and rates in memory. It follows the Postgres storage: every change
+// bumps the version, is recorded in the history and wakes up the listeners.
+type Storage struct {
+	mu         sync.RWMutex
+	currencies map[models.Currency]models.CurrencyInfo
+	rates      map[models.Currency]rate
+	history    []historyEntry
+	version    int64
+	updatedAt  time.Time
+	listeners  map[chan struct{}]struct{}
+}
+
+func NewStorage(seed *Seed) (*Storage, error) {
+
+	if err := seed.validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	s := &Storage{
+		currencies: make(map[models.Currency]models.CurrencyInfo, len(seed.Currencies)),
+		rates:      make(map[models.Currency]rate, len(seed.Rates)),
+		version:    1,
+		updatedAt:  now,
+		listeners:  make(map[chan struct{}]struct{}),
+	}
+
+	for _, currency := range seed.Currencies {
+		s.currencies[models.Currency(currency.Code)] = models.CurrencyInfo{
+			Code:    models.Currency(currency.Code),
+			Name:    currency.Name,
+			Scale:   currency.Scale,
+			Enabled: currency.Enabled,
+		}
+	}
+	for currency, value := range seed.Rates {
+		s.rates[models.Currency(currency)] = rate{value: value, source: seedSource, fetchedAt: now}
+		s.history = append(s.history, historyEntry{currency: models.Currency(currency), rate: value, validFrom: now})
+	}
+
+	return s, nil
+}
+
+func (s *Storage) GetCurrencies(ctx context.Context) ([]models.CurrencyInfo, error) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	currencies := make([]models.CurrencyInfo, 0, len(s.currencies))
+	for _, currency := range s.currencies {
+		currencies = append(currencies, currency)
+	}
+	slices.SortFunc(currencies, func(a, b models.CurrencyInfo) int {
+		return cmp.Compare(a.Code, b.Code)
+	})
+	return currencies, nil
+}
+
+func (s *Storage) GetRate(ctx context.Context, from models.Currency, to models.Currency) (*models.Rate, error) {
+
+	snapshot, err := s.getSnapshot(true)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := snapshot.Rate(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Rate{RatesVersion: snapshot.RatesVersion, Value: value}, nil
+}
+
+// GetRates returns the rates of the enabled currencies.
+func (s *Storage) GetRates(ctx context.Context) (*models.RateSnapshot, error) {
+	return s.getSnapshot(true)
+}
+
+// GetRateSnapshot returns the rates of all the currencies, including disabled ones.
+func (s *Storage) GetRateSnapshot(ctx context.Context) (*models.RateSnapshot, error) {
+	return s.getSnapshot(false)
+}
+
+func (s *Storage) getSnapshot(enabledOnly bool) (*models.RateSnapshot, error) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := models.RateSnapshot{
+		RatesVersion: models.RatesVersion{Version: s.version, UpdatedAt: s.updatedAt},
+		Rates:        make(map[models.Currency]decimal.Decimal, len(s.rates)),
+	}
+	for currency, r := range s.rates {
+		if r.value.Equal(decimal.NewFromInt(1)) {
+			snapshot.Base = currency
+		}
+		if s.currencies[currency].Enabled || !enabledOnly {
+			snapshot.Rates[currency] = r.value
+		}
+	}
+
+	if snapshot.Base == "" {
+		return nil, fmt.Errorf("%w: no base currency", models.RateNotFound)
+	}
+	return &snapshot, nil
+}
+
+// GetRateAt returns the rate between two currencies that was in effect at the given time.
+func (s *Storage) GetRateAt(ctx context.Context, from models.Currency, to models.Currency,
+	at time.Time) (*models.Rate, error) {
+
+	s.mu.RLock()
+	snapshot := models.RateSnapshot{Rates: make(map[models.Currency]decimal.Decimal)}
+	for _, entry := range s.history {
+		if entry.validFrom.After(at) || (!entry.validTo.IsZero() && !entry.validTo.After(at)) {
+			continue
+		}
+
+		snapshot.Rates[entry.currency] = entry.rate
+		if entry.rate.Equal(decimal.NewFromInt(1)) {
+			snapshot.Base = entry.currency
+		}
+		if entry.validFrom.After(snapshot.UpdatedAt) {
+			snapshot.UpdatedAt = entry.validFrom
+		}
+	}
+	s.mu.RUnlock()
+
+	if snapshot.Base == "" {
+		return nil, fmt.Errorf("%w: no base currency at %s", models.RateNotFound, at)
+	}
+
+	value, err := snapshot.Rate(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Rate{RatesVersion: snapshot.RatesVersion, Value: value}, nil
+}
+
+func (s *Storage) GetBaseCurrency(ctx context.Context) (models.Currency, error) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for currency, r := range s.rates {
+		if r.value.Equal(decimal.NewFromInt(1)) {
+			return currency, nil
+		}
+	}
+	return "", fmt.Errorf("%w: no base currency", models.RateNotFound)
+}
+
+func (s *Storage) SetRate(ctx context.Context, currency models.Currency, value decimal.Decimal) error {
+	return s.SetRates(ctx, map[models.Currency]decimal.Decimal{currency: value})
+}
+
+// SetRates creates or updates all the rates at once.
+func (s *Storage) SetRates(ctx context.Context, rates map[models.Currency]decimal.Decimal) error {
+	now := time.Now()
+	return s.ImportRates(ctx, rates, adminSource, now, now)
+}
+
+// ImportRates creates or updates all the rates at once, recording where and when they were fetched.
+// The changed rates enter the history at effectiveAt, unless it is in the future or before the current rates.
+func (s *Storage) ImportRates(ctx context.Context, rates map[models.Currency]decimal.Decimal, source string,
+	fetchedAt, effectiveAt time.Time) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for currency, value := range rates {
+		if _, ok := s.currencies[currency]; !ok {
+			return fmt.Errorf("failed to set rate: unknown currency %s", currency)
+		}
+		if value.IsNegative() {
+			return fmt.Errorf("%w: %s must not be negative", models.InvalidRate, currency)
+		}
+	}
+
+	now := time.Now()
+	for currency, value := range rates {
+		if current, ok := s.rates[currency]; !ok || !current.value.Equal(value) {
+			validFrom := s.historyStart(currency, effectiveAt, now)
+			s.closeHistory(currency, validFrom)
+			s.history = append(s.history, historyEntry{currency: currency, rate: value, validFrom: validFrom})
+		}
+		s.rates[currency] = rate{value: value, source: source, fetchedAt: fetchedAt}
+	}
+
+	s.changed(now)
+	return nil
+}
+
+// SetCurrencyEnabled enables or disables the currency, leaving its rate alone.
+func (s *Storage) SetCurrencyEnabled(ctx context.Context, currency models.Currency, enabled bool) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, ok := s.currencies[currency]
+	if !ok {
+		return fmt.Errorf("%w: %s", models.CurrencyNotFound, currency)
+	}
+
+	info.Enabled = enabled
+	s.currencies[currency] = info
+
+	s.changed(time.Now())
+	return nil
+}
+
+// ListenRateChanges calls onChange once right away and then every time the rates are changed,
+// until ctx is done.
+func (s *Storage) ListenRateChanges(ctx context.Context, onChange func()) error {
+
+	ch := make(chan struct{}, 1)
+
+	s.mu.Lock()
+	s.listeners[ch] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, ch)
+		s.mu.Unlock()
+	}()
+
+	onChange()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ch:
+			onChange()
+		}
+	}
+}
+
+// historyStart clamps the time a new rate of the currency took effect to now and to the recorded history
+// of the currency, which is never rewritten. The caller must hold the lock.
+func (s *Storage) historyStart(currency models.Currency, effectiveAt, now time.Time) time.Time {
+
+	start := effectiveAt
+	if start.After(now) {
+		start = now
+	}
+	for _, entry := range s.history {
+		if entry.currency != currency {
+			continue
+		}
+		if entry.validFrom.After(start) {
+			start = entry.validFrom
+		}
+		if entry.validTo.After(start) {
+			start = entry.validTo
+		}
+	}
+	return start
+}
+
+// closeHistory ends the current history entry of the currency. The caller must hold the write lock.
+func (s *Storage) closeHistory(currency models.Currency, at time.Time) {
+	for i := range s.history {
+		if s.history[i].currency == currency && s.history[i].validTo.IsZero() {
+			s.history[i].validTo = at
+		}
+	}
+}
+
+// changed bumps the version and wakes up the listeners. The caller must hold the write lock.
+func (s *Storage) changed(at time.Time) {
+
+	s.version++
+	s.updatedAt = at
+
+	for ch := range s.listeners {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"test-task/exchanger/internal/models"
+	"testing"
+	"time"
+)
+
+const yamlSeed = `
+currencies:
+  - {code: USD, name: US Dollar, scale: 2, enabled: true}
+  - {code: EUR, name: Euro, scale: 2, enabled: true}
+  - {code: RUB, name: Russian Ruble, scale: 2, enabled: false}
+rates:
+  USD: 1
+  EUR: 0.85
+  RUB: "0.1"
+`
+
+const jsonSeed = `{
+  "currencies": [
+    {"code": "USD", "name": "US Dollar", "scale": 2, "enabled": true},
+    {"code": "EUR", "name": "Euro", "scale": 2, "enabled": true}
+  ],
+  "rates": {"USD": 1, "EUR": "0.85"}
+}`
+
+func loadSeed(t *testing.T, name string, content string) *Storage {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	storage, err := Load(path)
+	require.NoError(t, err)
+	return storage
+}
+
+func Test_Load_ShouldReadYamlAndJson(t *testing.T) {
+
+	for name, content := range map[string]string{"rates.yaml": yamlSeed, "rates.json": jsonSeed} {
+		storage := loadSeed(t, name, content)
+
+		base, err := storage.GetBaseCurrency(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, models.USD, base)
+
+		rate, err := storage.GetRate(context.Background(), models.USD, models.EUR)
+		require.NoError(t, err)
+		assert.True(t, decimal.RequireFromString("0.85").Equal(rate.Value), name)
+	}
+}
+
+func Test_Load_ShouldRejectSeedWithoutSingleBase(t *testing.T) {
+
+	seed := &Seed{
+		Currencies: []SeedCurrency{{Code: "USD", Scale: 2, Enabled: true}, {Code: "EUR", Scale: 2, Enabled: true}},
+		Rates:      map[string]decimal.Decimal{"USD": decimal.NewFromInt(1), "EUR": decimal.NewFromInt(1)},
+	}
+
+	_, err := NewStorage(seed)
+	assert.Error(t, err)
+}
+
+func Test_GetRates_ShouldSkipDisabledCurrencies(t *testing.T) {
+
+	storage := loadSeed(t, "rates.yaml", yamlSeed)
+
+	snapshot, err := storage.GetRates(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, snapshot.Rates, 2)
+	assert.Equal(t, models.USD, snapshot.Base)
+
+	snapshot, err = storage.GetRateSnapshot(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, snapshot.Rates, 3)
+}
+
+func Test_SetRate_ShouldBumpVersionAndKeepHistory(t *testing.T) {
+
+	storage := loadSeed(t, "rates.yaml", yamlSeed)
+	ctx := context.Background()
+
+	before, err := storage.GetRate(ctx, models.USD, models.EUR)
+	require.NoError(t, err)
+
+	changed := make(chan struct{}, 2)
+	listenCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go storage.ListenRateChanges(listenCtx, func() { changed <- struct{}{} })
+	<-changed
+
+	time.Sleep(time.Millisecond)
+	require.NoError(t, storage.SetRate(ctx, models.EUR, decimal.RequireFromString("0.9")))
+
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("listener was not notified")
+	}
+
+	after, err := storage.GetRate(ctx, models.USD, models.EUR)
+	require.NoError(t, err)
+	assert.True(t, decimal.RequireFromString("0.9").Equal(after.Value))
+	assert.Greater(t, after.Version, before.Version)
+
+	old, err := storage.GetRateAt(ctx, models.USD, models.EUR, before.UpdatedAt)
+	require.NoError(t, err)
+	assert.True(t, decimal.RequireFromString("0.85").Equal(old.Value))
+}
+
+func Test_ImportRates_ShouldTakeEffectAtSourceTime(t *testing.T) {
+
+	storage := loadSeed(t, "rates.yaml", yamlSeed)
+	ctx := context.Background()
+
+	effectiveAt := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	eur := map[models.Currency]decimal.Decimal{models.EUR: decimal.RequireFromString("0.9")}
+	require.NoError(t, storage.ImportRates(ctx, eur, "test", time.Now(), effectiveAt))
+
+	rate, err := storage.GetRateAt(ctx, models.USD, models.EUR, effectiveAt)
+	require.NoError(t, err)
+	assert.True(t, decimal.RequireFromString("0.9").Equal(rate.Value))
+
+	// the recorded history is kept, the rates dated before it take effect after it
+	longAgo := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	eur[models.EUR] = decimal.RequireFromString("0.95")
+	require.NoError(t, storage.ImportRates(ctx, eur, "test", time.Now(), longAgo))
+
+	_, err = storage.GetRateAt(ctx, models.USD, models.EUR, longAgo)
+	assert.ErrorIs(t, err, models.RateNotFound)
+
+	rate, err = storage.GetRateAt(ctx, models.USD, models.EUR, effectiveAt)
+	require.NoError(t, err)
+	assert.True(t, decimal.RequireFromString("0.95").Equal(rate.Value))
+}
+
+func Test_SetCurrencyEnabled_ShouldKeepRate(t *testing.T) {
+
+	storage := loadSeed(t, "rates.json", jsonSeed)
+	ctx := context.Background()
+
+	before, err := storage.GetRate(ctx, models.USD, models.EUR)
+	require.NoError(t, err)
+
+	require.NoError(t, storage.SetCurrencyEnabled(ctx, models.EUR, false))
+
+	_, err = storage.GetRate(ctx, models.USD, models.EUR)
+	assert.ErrorIs(t, err, models.RateNotFound)
+
+	require.NoError(t, storage.SetCurrencyEnabled(ctx, models.EUR, true))
+
+	after, err := storage.GetRate(ctx, models.USD, models.EUR)
+	require.NoError(t, err)
+	assert.True(t, before.Value.Equal(after.Value))
+	assert.Greater(t, after.Version, before.Version)
+
+	err = storage.SetCurrencyEnabled(ctx, "XYZ", false)
+	assert.ErrorIs(t, err, models.CurrencyNotFound)
+}
+
+func Test_SetRate_ShouldRejectUnknownCurrency(t *testing.T) {
+
+	storage := loadSeed(t, "rates.json", jsonSeed)
+
+	err := storage.SetRate(context.Background(), "GBP", decimal.RequireFromString("0.8"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"test-task/exchanger/internal/models"
+)
+
+func (p *Storage) GetCurrencies(ctx context.Context) ([]models.CurrencyInfo, error) {
+
+	rows, err := p.pool.Query(ctx, "SELECT code, name, scale, enabled FROM currencies")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch currencies from DB: %w", err)
+	}
+	defer rows.Close()
+
+	var currencies []models.CurrencyInfo
+	for rows.Next() {
+		var currency models.CurrencyInfo
+
+		err = rows.Scan(&currency.Code, &currency.Name, &currency.Scale, &currency.Enabled)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row during currencies fetching: %w", err)
+		}
+		currencies = append(currencies, currency)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("failed to fetch currencies from DB: %w", rows.Err())
+	}
+
+	return currencies, nil
+}
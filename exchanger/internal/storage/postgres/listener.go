@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+const ratesChangedChannel = "exchange_rates_changed"
+
+// ListenRateChanges calls onChange every time exchange_rates is changed, until ctx is done or the
+// connection fails. onChange is also called once listening has started, as changes made while
+// nobody was listening are lost.
+func (p *Storage) ListenRateChanges(ctx context.Context, onChange func()) error {
+
+	pooled, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	// the connection stays subscribed, so it must not go back to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, "LISTEN "+ratesChangedChannel)
+	if err != nil {
+		return fmt.Errorf("failed to listen to rate changes: %w", err)
+	}
+	onChange()
+
+	for {
+		_, err = conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to wait for rate changes: %w", err)
+		}
+		onChange()
+	}
+}
@@ -3,83 +3,161 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
 	"test-task/exchanger/internal/models"
+	"time"
 )
 
+type executor interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+}
+
 type Storage struct {
-	pool         *pgxpool.Pool
-	baseCurrency models.Currency
+	pool *pgxpool.Pool
+}
+
+func NewStorage(pool *pgxpool.Pool) *Storage {
+	return &Storage{pool: pool}
 }
 
-func NewStorage(pool *pgxpool.Pool) (*Storage, error) {
+// GetRate returns the rate between two enabled currencies along with the version of the rates. Both rates
+// and the base currency are read with a single query, so they always belong to the same version.
+func (p *Storage) GetRate(ctx context.Context, from models.Currency, to models.Currency) (*models.Rate, error) {
+
+	snapshot, err := p.getSnapshot(ctx, true, from, to)
+	if err != nil {
+		return nil, err
+	}
 
-	baseCurrency, err := getBaseCurrency(context.Background(), pool)
+	value, err := snapshot.Rate(from, to)
 	if err != nil {
-		return nil, fmt.Errorf("failed to set base currency: %w", err)
+		return nil, err
 	}
 
-	return &Storage{pool: pool, baseCurrency: baseCurrency}, nil
+	return &models.Rate{RatesVersion: snapshot.RatesVersion, Value: value}, nil
 }
 
-func (p *Storage) SetBaseCurrency(currency models.Currency) {
-	p.baseCurrency = currency
+// GetRates returns the rates of the enabled currencies.
+func (p *Storage) GetRates(ctx context.Context) (*models.RateSnapshot, error) {
+	return p.getSnapshot(ctx, true)
 }
 
-func (p *Storage) GetRate(ctx context.Context, from models.Currency, to models.Currency) (float64, error) {
+// GetRateSnapshot returns the rates of all the currencies, including disabled ones.
+func (p *Storage) GetRateSnapshot(ctx context.Context) (*models.RateSnapshot, error) {
+	return p.getSnapshot(ctx, false)
+}
+
+// getSnapshot reads the rates with their version and the base currency in a single query, so they are
+// consistent with each other. If currencies are given, only their rates are read.
+func (p *Storage) getSnapshot(ctx context.Context, enabledOnly bool,
+	currencies ...models.Currency) (*models.RateSnapshot, error) {
+
+	query := `SELECT r.currency, r.rate, c.enabled, v.version, v.updated_at FROM exchange_rates r
+        JOIN currencies c ON c.code = r.currency CROSS JOIN exchange_rates_version v`
+	var args []any
+	if len(currencies) > 0 {
+		codes := make([]string, len(currencies))
+		for i, currency := range currencies {
+			codes[i] = string(currency)
+		}
+		// the base currency is the one with the rate of 1, so it is always read along
+		query += " WHERE r.currency = ANY($1) OR r.rate = 1"
+		args = append(args, codes)
+	}
+
+	snapshot := models.RateSnapshot{Rates: make(map[models.Currency]decimal.Decimal)}
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rates from DB: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var currency models.Currency
+		var rate decimal.Decimal
+		var enabled bool
 
-	if from == to {
-		return 1, nil
+		err = rows.Scan(&currency, &rate, &enabled, &snapshot.Version, &snapshot.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row during rates fetching: %w", err)
+		}
+
+		if rate.Equal(decimal.NewFromInt(1)) {
+			snapshot.Base = currency
+		}
+		if enabled || !enabledOnly {
+			snapshot.Rates[currency] = rate
+		}
 	}
 
-	if from == p.baseCurrency {
-		return p.getRate(ctx, to)
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("failed to fetch rates from DB: %w", rows.Err())
 	}
 
-	if to == p.baseCurrency {
-		rate, err := p.getRate(ctx, from)
-		return 1 / rate, err
+	if snapshot.Base == "" {
+		return nil, fmt.Errorf("%w: no base currency", models.RateNotFound)
 	}
+	return &snapshot, nil
+}
 
-	from_rate, err := p.getRate(ctx, from)
+// GetRateAt returns the rate between two currencies that was in effect at the given time.
+func (p *Storage) GetRateAt(ctx context.Context, from models.Currency, to models.Currency,
+	at time.Time) (*models.Rate, error) {
+
+	snapshot, err := p.getRatesAt(ctx, at)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get first rate: %w", err)
+		return nil, err
 	}
 
-	to_rate, err := p.getRate(ctx, to)
+	value, err := snapshot.Rate(from, to)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get second rate: %w", err)
+		return nil, err
 	}
 
-	return from_rate / to_rate, nil
+	return &models.Rate{RatesVersion: snapshot.RatesVersion, Value: value}, nil
 }
 
-func (p *Storage) GetRates(ctx context.Context) (map[models.Currency]float64, error) {
+// getRatesAt returns the rates that were in effect at the given time. UpdatedAt of the snapshot is the time
+// the latest of them took effect.
+func (p *Storage) getRatesAt(ctx context.Context, at time.Time) (*models.RateSnapshot, error) {
 
-	rates := make(map[models.Currency]float64)
-	rows, err := p.pool.Query(ctx, "SELECT currency, rate FROM exchange_rates")
+	snapshot := models.RateSnapshot{Rates: make(map[models.Currency]decimal.Decimal)}
+	rows, err := p.pool.Query(ctx, `SELECT currency, rate, valid_from FROM exchange_rates_history
+        WHERE valid_from <= $1 AND (valid_to IS NULL OR valid_to > $1)`, at)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch rates from DB: %w", err)
+		return nil, fmt.Errorf("failed to fetch rates history from DB: %w", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var currency models.Currency
-		rate := 0.0
+		var rate decimal.Decimal
+		var validFrom time.Time
 
-		err = rows.Scan(&currency, &rate)
+		err = rows.Scan(&currency, &rate, &validFrom)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan row during rates fetching: %w", err)
+			return nil, fmt.Errorf("failed to scan row during rates history fetching: %w", err)
 		}
 
-		rates[currency] = rate
+		snapshot.Rates[currency] = rate
+		if rate.Equal(decimal.NewFromInt(1)) {
+			snapshot.Base = currency
+		}
+		if validFrom.After(snapshot.UpdatedAt) {
+			snapshot.UpdatedAt = validFrom
+		}
 	}
 
 	if rows.Err() != nil {
-		return nil, fmt.Errorf("failed to fetch rates from DB: %w", rows.Err())
+		return nil, fmt.Errorf("failed to fetch rates history from DB: %w", rows.Err())
 	}
 
-	return rates, nil
+	if snapshot.Base == "" {
+		return nil, fmt.Errorf("%w: no base currency at %s", models.RateNotFound, at)
+	}
+	return &snapshot, nil
 }
 
 func (p *Storage) GetBaseCurrency(ctx context.Context) (models.Currency, error) {
@@ -92,40 +170,68 @@ func (p *Storage) GetBaseCurrency(ctx context.Context) (models.Currency, error)
 		return "", err
 	}
 
-	currency := models.Currency(currencyStr)
-	if !currency.IsValid() {
-		return "", fmt.Errorf("invalid currency '%s'", currencyStr)
-	}
+	// exchange_rates references currencies, so the code is known even if not loaded into the registry yet
+	return models.Currency(currencyStr), nil
+}
+
+// adminSource is recorded as the source of the rates set through the admin API.
+const adminSource = "admin"
 
-	return currency, nil
+func (p *Storage) SetRate(ctx context.Context, currency models.Currency, rate decimal.Decimal) error {
+	now := time.Now()
+	return setRate(ctx, p.pool, currency, rate, adminSource, now, now)
 }
 
-func (p *Storage) getRate(ctx context.Context, currency models.Currency) (float64, error) {
+// SetRates creates or updates all the rates in one transaction.
+func (p *Storage) SetRates(ctx context.Context, rates map[models.Currency]decimal.Decimal) error {
+	now := time.Now()
+	return p.ImportRates(ctx, rates, adminSource, now, now)
+}
 
-	rate := 0.0
-	row := p.pool.QueryRow(ctx, "SELECT rate FROM exchange_rates WHERE currency = $1", string(currency))
+// ImportRates creates or updates all the rates in one transaction, recording where and when they were fetched.
+// The changed rates enter the history at effectiveAt, unless it is in the future or before the current rates.
+func (p *Storage) ImportRates(ctx context.Context, rates map[models.Currency]decimal.Decimal, source string,
+	fetchedAt, effectiveAt time.Time) error {
 
-	err := row.Scan(&rate)
+	tx, err := p.pool.Begin(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch rate from DB: %w", err)
+		return err
 	}
-	return rate, nil
-}
+	defer tx.Rollback(ctx)
 
-func getBaseCurrency(ctx context.Context, pool *pgxpool.Pool) (models.Currency, error) {
+	for currency, rate := range rates {
+		if err = setRate(ctx, tx, currency, rate, source, fetchedAt, effectiveAt); err != nil {
+			return err
+		}
+	}
 
-	var currencyStr string
-	res := pool.QueryRow(ctx, "SELECT currency FROM exchange_rates WHERE rate = 1")
+	return tx.Commit(ctx)
+}
 
-	err := res.Scan(&currencyStr)
+// SetCurrencyEnabled enables or disables the currency, leaving its rate alone. Changing the currency bumps
+// the version of the rates and wakes up their listeners, as the enabled rates change with it.
+func (p *Storage) SetCurrencyEnabled(ctx context.Context, currency models.Currency, enabled bool) error {
+
+	tag, err := p.pool.Exec(ctx, "UPDATE currencies SET enabled = $2 WHERE code = $1", string(currency), enabled)
 	if err != nil {
-		return "", err
+		return fmt.Errorf("failed to update currency in DB: %w", err)
 	}
-
-	currency := models.Currency(currencyStr)
-	if !currency.IsValid() {
-		return "", fmt.Errorf("invalid currency '%s'", currencyStr)
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", models.CurrencyNotFound, currency)
 	}
+	return nil
+}
 
-	return currency, nil
+func setRate(ctx context.Context, executor executor, currency models.Currency, rate decimal.Decimal,
+	source string, fetchedAt, effectiveAt time.Time) error {
+
+	_, err := executor.Exec(ctx, `INSERT INTO exchange_rates (currency, rate, source, fetched_at, effective_at)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (currency) DO UPDATE SET rate = EXCLUDED.rate, source = EXCLUDED.source,
+            fetched_at = EXCLUDED.fetched_at, effective_at = EXCLUDED.effective_at`,
+		string(currency), rate, source, fetchedAt, effectiveAt)
+	if err != nil {
+		return fmt.Errorf("failed to set rate in DB: %w", err)
+	}
+	return nil
 }
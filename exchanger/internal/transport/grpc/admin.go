@@ -0,0 +1,141 @@
+package grpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"strings"
+	"test-task/api/gen/grpc/exchange"
+	"test-task/exchanger/internal/models"
+)
+
+type AdminService interface {
+	SetRate(ctx context.Context, currency models.Currency, rate decimal.Decimal) error
+	SetRates(ctx context.Context, rates map[models.Currency]decimal.Decimal) error
+	DeactivateCurrency(ctx context.Context, currency models.Currency) error
+	ActivateCurrency(ctx context.Context, currency models.Currency) error
+}
+
+type AdminServer struct {
+	service AdminService
+	exchange.UnimplementedExchangeAdminServer
+}
+
+func NewAdminServer(service AdminService) *AdminServer {
+	return &AdminServer{service: service}
+}
+
+func (a AdminServer) SetRate(ctx context.Context, in *exchange.SetRateRequest) (*emptypb.Empty, error) {
+
+	currency := models.Currency(in.Currency)
+	if !currency.IsKnown() {
+		return nil, status.Error(codes.InvalidArgument, "invalid currency")
+	}
+
+	rate, err := decimal.NewFromString(in.Rate)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid rate")
+	}
+
+	if err = a.service.SetRate(ctx, currency, rate); err != nil {
+		return nil, adminError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (a AdminServer) UploadRates(ctx context.Context, in *exchange.UploadRatesRequest) (*emptypb.Empty, error) {
+
+	if len(in.Rates) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no rates")
+	}
+
+	rates := make(map[models.Currency]decimal.Decimal, len(in.Rates))
+	for key, value := range in.Rates {
+		currency := models.Currency(key)
+		if !currency.IsKnown() {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid currency '%s'", key)
+		}
+
+		rate, err := decimal.NewFromString(value)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid rate of '%s'", key)
+		}
+		rates[currency] = rate
+	}
+
+	if err := a.service.SetRates(ctx, rates); err != nil {
+		return nil, adminError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (a AdminServer) DeactivateCurrency(ctx context.Context, in *exchange.DeactivateCurrencyRequest) (*emptypb.Empty, error) {
+
+	currency := models.Currency(in.Currency)
+	if !currency.IsKnown() {
+		return nil, status.Error(codes.InvalidArgument, "invalid currency")
+	}
+
+	if err := a.service.DeactivateCurrency(ctx, currency); err != nil {
+		return nil, adminError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (a AdminServer) ActivateCurrency(ctx context.Context, in *exchange.ActivateCurrencyRequest) (*emptypb.Empty, error) {
+
+	currency := models.Currency(in.Currency)
+	if !currency.IsKnown() {
+		return nil, status.Error(codes.InvalidArgument, "invalid currency")
+	}
+
+	if err := a.service.ActivateCurrency(ctx, currency); err != nil {
+		return nil, adminError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func adminError(err error) error {
+	switch {
+	case errors.Is(err, models.InvalidRate), errors.Is(err, models.BaseCurrencyRate),
+		errors.Is(err, models.BaseCurrencyDeactivation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, models.RateNotFound), errors.Is(err, models.CurrencyNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return err
+	}
+}
+
+// AdminAuthInterceptor rejects calls to the admin service that do not carry the admin token
+// as "authorization: Bearer <token>" metadata. Calls to other services pass through.
+func AdminAuthInterceptor(token string) grpc.UnaryServerInterceptor {
+
+	prefix := "/" + exchange.ExchangeAdmin_ServiceDesc.ServiceName + "/"
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+
+		if !strings.HasPrefix(info.FullMethod, prefix) {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("authorization")
+		if len(values) == 0 {
+			return nil, status.Error(codes.Unauthenticated, "missing admin token")
+		}
+
+		provided, ok := strings.CutPrefix(values[0], "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "invalid admin token")
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -2,18 +2,30 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"maps"
 	"test-task/api/gen/grpc/exchange"
 	"test-task/exchanger/internal/models"
+	"time"
 )
 
 type ExchangeService interface {
-	GetRate(ctx context.Context, from models.Currency, to models.Currency) (float64, error)
-	GetRates(ctx context.Context) (map[models.Currency]float64, error)
+	GetRate(ctx context.Context, from models.Currency, to models.Currency) (*models.Rate, error)
+	GetRates(ctx context.Context) (*models.RateSnapshot, error)
+	GetRateAt(ctx context.Context, from models.Currency, to models.Currency, at time.Time) (*models.Rate, error)
+	Convert(ctx context.Context, conversions []models.Conversion) ([]models.Conversion, error)
+	SubscribeRates() (<-chan struct{}, func())
 }
 
+// maxBatchSize limits the number of items of one ConvertBatch call.
+const maxBatchSize = 10000
+
 type ExchangeServer struct {
 	service ExchangeService
 	exchange.UnimplementedExchangeServer
@@ -25,17 +37,71 @@ func NewExchangeServer(service ExchangeService) *ExchangeServer {
 
 func (e ExchangeServer) GetExchangeRates(ctx context.Context, in *emptypb.Empty) (*exchange.ExchangeRatesResponse, error) {
 
-	rates, err := e.service.GetRates(ctx)
+	return e.getRates(ctx)
+}
+
+func (e ExchangeServer) StreamExchangeRates(in *emptypb.Empty, stream grpc.ServerStreamingServer[exchange.ExchangeRatesResponse]) error {
+
+	changes, cancel := e.service.SubscribeRates()
+	defer cancel()
+
+	var sent map[string]string
+	for {
+		resp, err := e.getRates(stream.Context())
+		if err != nil {
+			return err
+		}
+
+		// the same rates can be written again, e.g. by a bulk upload, which only bumps the version
+		if sent == nil || !maps.Equal(resp.Rates, sent) {
+			if err = stream.Send(resp); err != nil {
+				return err
+			}
+			sent = resp.Rates
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case _, ok := <-changes:
+			if !ok {
+				return status.Error(codes.Unavailable, "server is shutting down")
+			}
+		}
+	}
+}
+
+func (e ExchangeServer) getRates(ctx context.Context) (*exchange.ExchangeRatesResponse, error) {
+
+	snapshot, err := e.service.GetRates(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	convertedRates := make(map[string]float64)
-	for key, value := range rates {
-		convertedRates[string(key)] = value
+	convertedRates := make(map[string]string)
+	legacyRates := make(map[string]float64)
+	for key, value := range snapshot.Rates {
+		convertedRates[string(key)] = value.String()
+		legacyRates[string(key)] = value.InexactFloat64()
 	}
 
-	return &exchange.ExchangeRatesResponse{Rates: convertedRates}, nil
+	return &exchange.ExchangeRatesResponse{
+		Rates:        convertedRates,
+		LegacyRates:  legacyRates,
+		EffectiveAt:  timestamppb.New(snapshot.UpdatedAt),
+		Version:      snapshot.Version,
+		BaseCurrency: string(snapshot.Base),
+	}, nil
+}
+
+func rateResponse(rate *models.Rate) *exchange.ExchangeRateResponse {
+	return &exchange.ExchangeRateResponse{
+		Rate:         rate.Value.String(),
+		LegacyRate:   rate.Value.InexactFloat64(),
+		EffectiveAt:  timestamppb.New(rate.UpdatedAt),
+		Version:      rate.Version,
+		BaseCurrency: string(rate.Base),
+	}
 }
 
 func (e ExchangeServer) GetExchangeRateForOne(ctx context.Context, in *exchange.ExchangeRateRequest) (*exchange.ExchangeRateResponse, error) {
@@ -52,8 +118,88 @@ func (e ExchangeServer) GetExchangeRateForOne(ctx context.Context, in *exchange.
 
 	res, err := e.service.GetRate(ctx, from, to)
 	if err != nil {
+		if errors.Is(err, models.RateNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
 		return nil, err
 	}
 
-	return &exchange.ExchangeRateResponse{Rate: res}, nil
+	return rateResponse(res), nil
+}
+
+func (e ExchangeServer) GetExchangeRateAt(ctx context.Context, in *exchange.ExchangeRateAtRequest) (*exchange.ExchangeRateResponse, error) {
+
+	from := models.Currency(in.FromCurrency)
+	if !from.IsValid() {
+		return nil, status.Error(codes.InvalidArgument, "invalid currency to convert from")
+	}
+
+	to := models.Currency(in.ToCurrency)
+	if !to.IsValid() {
+		return nil, status.Error(codes.InvalidArgument, "invalid currency to convert to")
+	}
+
+	if in.At == nil || !in.At.IsValid() {
+		return nil, status.Error(codes.InvalidArgument, "invalid timestamp")
+	}
+
+	res, err := e.service.GetRateAt(ctx, from, to, in.At.AsTime())
+	if err != nil {
+		if errors.Is(err, models.RateNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, err
+	}
+
+	return rateResponse(res), nil
+}
+
+func (e ExchangeServer) ConvertBatch(ctx context.Context, in *exchange.ConvertBatchRequest) (*exchange.ConvertBatchResponse, error) {
+
+	if len(in.Items) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no items")
+	}
+	if len(in.Items) > maxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "too many items, at most %d allowed", maxBatchSize)
+	}
+
+	conversions := make([]models.Conversion, len(in.Items))
+	for i, item := range in.Items {
+		from := models.Currency(item.FromCurrency)
+		if !from.IsValid() {
+			return nil, status.Errorf(codes.InvalidArgument, "item %d: invalid currency to convert from", i)
+		}
+
+		to := models.Currency(item.ToCurrency)
+		if !to.IsValid() {
+			return nil, status.Errorf(codes.InvalidArgument, "item %d: invalid currency to convert to", i)
+		}
+
+		amount, err := decimal.NewFromString(item.Amount)
+		if err != nil || amount.IsNegative() {
+			return nil, status.Errorf(codes.InvalidArgument, "item %d: invalid amount", i)
+		}
+
+		conversions[i] = models.Conversion{From: from, To: to, Amount: amount}
+	}
+
+	conversions, err := e.service.Convert(ctx, conversions)
+	if err != nil {
+		if errors.Is(err, models.RateNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, err
+	}
+
+	res := &exchange.ConvertBatchResponse{Results: make([]*exchange.ConversionResult, len(conversions))}
+	for i, conversion := range conversions {
+		res.Results[i] = &exchange.ConversionResult{
+			FromCurrency:    string(conversion.From),
+			ToCurrency:      string(conversion.To),
+			Amount:          conversion.Amount.String(),
+			ConvertedAmount: conversion.ConvertedAmount.String(),
+			Rate:            conversion.Rate.String(),
+		}
+	}
+	return res, nil
 }
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS exchange_rates_history ON exchange_rates;
+DROP FUNCTION IF EXISTS exchange_rates_record_history();
+DROP TABLE IF EXISTS exchange_rates_history;
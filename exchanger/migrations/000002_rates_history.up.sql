@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS exchange_rates_history (
+    id BIGSERIAL PRIMARY KEY,
+    currency TEXT NOT NULL references currencies(code),
+    rate DECIMAL NOT NULL CHECK ( rate >= 0 ),
+    valid_from TIMESTAMPTZ NOT NULL,
+    valid_to TIMESTAMPTZ CHECK ( valid_to >= valid_from )
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS exchange_rates_history_current_idx
+    ON exchange_rates_history (currency) WHERE valid_to IS NULL;
+CREATE INDEX IF NOT EXISTS exchange_rates_history_currency_valid_from_idx
+    ON exchange_rates_history (currency, valid_from);
+
+INSERT INTO exchange_rates_history (currency, rate, valid_from)
+SELECT currency, rate, now() FROM exchange_rates;
+
+-- every change of exchange_rates closes the current interval of the currency and opens a new one
+CREATE OR REPLACE FUNCTION exchange_rates_record_history() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.currency = OLD.currency AND NEW.rate = OLD.rate THEN
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE exchange_rates_history SET valid_to = now()
+        WHERE currency = OLD.currency AND valid_to IS NULL;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO exchange_rates_history (currency, rate, valid_from) VALUES (NEW.currency, NEW.rate, now());
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER exchange_rates_history
+    AFTER INSERT OR UPDATE OR DELETE ON exchange_rates
+    FOR EACH ROW EXECUTE FUNCTION exchange_rates_record_history();
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS exchange_rates_notify ON exchange_rates;
+DROP FUNCTION IF EXISTS exchange_rates_notify();
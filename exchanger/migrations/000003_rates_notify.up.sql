@@ -0,0 +1,11 @@
+-- wakes up the listeners of exchange_rates_changed once per changing statement, delivered on commit
+CREATE OR REPLACE FUNCTION exchange_rates_notify() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('exchange_rates_changed', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER exchange_rates_notify
+    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON exchange_rates
+    FOR EACH STATEMENT EXECUTE FUNCTION exchange_rates_notify();
@@ -0,0 +1,8 @@
+-- the currencies table is shared with the wallet, so every change must be idempotent
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS name TEXT NOT NULL DEFAULT '';
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS scale SMALLINT NOT NULL DEFAULT 2 CHECK ( scale BETWEEN 0 AND 18 );
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS enabled BOOLEAN NOT NULL DEFAULT TRUE;
+
+UPDATE currencies SET name = 'US Dollar' WHERE code = 'USD' AND name = '';
+UPDATE currencies SET name = 'Euro' WHERE code = 'EUR' AND name = '';
+UPDATE currencies SET name = 'Russian Ruble' WHERE code = 'RUB' AND name = '';
@@ -0,0 +1,24 @@
+CREATE OR REPLACE FUNCTION exchange_rates_record_history() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.currency = OLD.currency AND NEW.rate = OLD.rate THEN
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE exchange_rates_history SET valid_to = now()
+        WHERE currency = OLD.currency AND valid_to IS NULL;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO exchange_rates_history (currency, rate, valid_from) VALUES (NEW.currency, NEW.rate, now());
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE exchange_rates_history DROP COLUMN IF EXISTS fetched_at;
+ALTER TABLE exchange_rates_history DROP COLUMN IF EXISTS source;
+
+ALTER TABLE exchange_rates DROP COLUMN IF EXISTS fetched_at;
+ALTER TABLE exchange_rates DROP COLUMN IF EXISTS source;
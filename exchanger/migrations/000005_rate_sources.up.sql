@@ -0,0 +1,28 @@
+ALTER TABLE exchange_rates ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT 'seed';
+ALTER TABLE exchange_rates ADD COLUMN IF NOT EXISTS fetched_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+ALTER TABLE exchange_rates_history ADD COLUMN IF NOT EXISTS source TEXT;
+ALTER TABLE exchange_rates_history ADD COLUMN IF NOT EXISTS fetched_at TIMESTAMPTZ;
+
+UPDATE exchange_rates_history h SET source = r.source, fetched_at = r.fetched_at
+FROM exchange_rates r WHERE h.currency = r.currency AND h.valid_to IS NULL;
+
+CREATE OR REPLACE FUNCTION exchange_rates_record_history() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.currency = OLD.currency AND NEW.rate = OLD.rate THEN
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE exchange_rates_history SET valid_to = now()
+        WHERE currency = OLD.currency AND valid_to IS NULL;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO exchange_rates_history (currency, rate, source, fetched_at, valid_from)
+        VALUES (NEW.currency, NEW.rate, NEW.source, NEW.fetched_at, now());
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS exchange_rates_version ON exchange_rates;
+DROP FUNCTION IF EXISTS exchange_rates_bump_version();
+DROP TABLE IF EXISTS exchange_rates_version;
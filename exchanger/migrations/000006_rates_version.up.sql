@@ -0,0 +1,19 @@
+-- a single row with the version of exchange_rates, bumped by every changing statement
+CREATE TABLE IF NOT EXISTS exchange_rates_version (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK ( id ),
+    version BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+INSERT INTO exchange_rates_version (version, updated_at) VALUES (1, now()) ON CONFLICT DO NOTHING;
+
+CREATE OR REPLACE FUNCTION exchange_rates_bump_version() RETURNS trigger AS $$
+BEGIN
+    UPDATE exchange_rates_version SET version = version + 1, updated_at = now();
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER exchange_rates_version
+    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON exchange_rates
+    FOR EACH STATEMENT EXECUTE FUNCTION exchange_rates_bump_version();
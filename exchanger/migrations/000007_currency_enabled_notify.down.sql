@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS currencies_enabled_notify ON currencies;
+DROP TRIGGER IF EXISTS currencies_enabled_version ON currencies;
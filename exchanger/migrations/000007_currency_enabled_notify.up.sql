@@ -0,0 +1,9 @@
+-- enabling or disabling a currency changes the enabled rates, so it bumps their version and wakes up
+-- their listeners just like a change of exchange_rates
+CREATE TRIGGER currencies_enabled_version
+    AFTER UPDATE OF enabled ON currencies
+    FOR EACH STATEMENT EXECUTE FUNCTION exchange_rates_bump_version();
+
+CREATE TRIGGER currencies_enabled_notify
+    AFTER UPDATE OF enabled ON currencies
+    FOR EACH STATEMENT EXECUTE FUNCTION exchange_rates_notify();
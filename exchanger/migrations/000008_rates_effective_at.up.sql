@@ -0,0 +1,30 @@
+ALTER TABLE exchange_rates ADD COLUMN IF NOT EXISTS effective_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- the history of a changed rate starts when the rate took effect according to its source. It is clamped
+-- to now and to the start of the current rate, so that the recorded history is never rewritten.
+CREATE OR REPLACE FUNCTION exchange_rates_record_history() RETURNS trigger AS $$
+DECLARE
+    effective TIMESTAMPTZ := now();
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.currency = OLD.currency AND NEW.rate = OLD.rate THEN
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        SELECT GREATEST(LEAST(NEW.effective_at, now()), max(valid_from), max(valid_to)) INTO effective
+        FROM exchange_rates_history WHERE currency = NEW.currency;
+    END IF;
+
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE exchange_rates_history SET valid_to = effective
+        WHERE currency = OLD.currency AND valid_to IS NULL;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO exchange_rates_history (currency, rate, source, fetched_at, valid_from)
+        VALUES (NEW.currency, NEW.rate, NEW.source, NEW.fetched_at, effective);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
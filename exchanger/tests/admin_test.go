@@ -0,0 +1,131 @@
+package integration
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"test-task/api/gen/grpc/exchange"
+	"test-task/exchanger/internal/models"
+	"testing"
+)
+
+func adminContext() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+cfg.AdminToken)
+}
+
+// restoreRates puts the seeded rates back after a test changed them.
+func restoreRates(t *testing.T) {
+	t.Cleanup(func() {
+		_, err := adminClient.UploadRates(adminContext(), &exchange.UploadRatesRequest{Rates: convertRates(rates)})
+		require.NoError(t, err)
+	})
+}
+
+func requireCode(t *testing.T, err error, code codes.Code) {
+	require.Error(t, err)
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, code, st.Code())
+}
+
+func TestAdmin_Unauthenticated(t *testing.T) {
+
+	req := exchange.SetRateRequest{Currency: string(models.EUR), Rate: "0.9"}
+
+	_, err := adminClient.SetRate(context.Background(), &req)
+	requireCode(t, err, codes.Unauthenticated)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer wrong")
+	_, err = adminClient.SetRate(ctx, &req)
+	requireCode(t, err, codes.Unauthenticated)
+}
+
+func TestAdmin_SetRate(t *testing.T) {
+
+	restoreRates(t)
+
+	_, err := adminClient.SetRate(adminContext(), &exchange.SetRateRequest{Currency: string(models.EUR), Rate: "0.9"})
+	require.NoError(t, err)
+
+	resp, err := exchangeClient.GetExchangeRates(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, "0.9", resp.Rates[string(models.EUR)])
+}
+
+func TestAdmin_UploadRates(t *testing.T) {
+
+	restoreRates(t)
+
+	req := exchange.UploadRatesRequest{Rates: map[string]string{
+		string(models.USD): "1",
+		string(models.EUR): "0.8",
+		string(models.RUB): "0.0125",
+	}}
+	_, err := adminClient.UploadRates(adminContext(), &req)
+	require.NoError(t, err)
+
+	resp, err := exchangeClient.GetExchangeRates(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, req.Rates, resp.Rates)
+}
+
+func TestAdmin_InvalidRates(t *testing.T) {
+
+	_, err := adminClient.SetRate(adminContext(), &exchange.SetRateRequest{Currency: string(models.EUR), Rate: "-1"})
+	requireCode(t, err, codes.InvalidArgument)
+
+	_, err = adminClient.SetRate(adminContext(), &exchange.SetRateRequest{Currency: string(models.USD), Rate: "2"})
+	requireCode(t, err, codes.InvalidArgument)
+
+	_, err = adminClient.SetRate(adminContext(), &exchange.SetRateRequest{Currency: string(models.EUR), Rate: "1"})
+	requireCode(t, err, codes.InvalidArgument)
+
+	// one invalid rate rejects the whole upload
+	_, err = adminClient.UploadRates(adminContext(), &exchange.UploadRatesRequest{Rates: map[string]string{
+		string(models.EUR): "0.9",
+		string(models.RUB): "0",
+	}})
+	requireCode(t, err, codes.InvalidArgument)
+
+	resp, err := exchangeClient.GetExchangeRates(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, convertRates(rates), resp.Rates)
+}
+
+func TestAdmin_DeactivateCurrency(t *testing.T) {
+
+	restoreRates(t)
+	t.Cleanup(func() {
+		_, err := adminClient.ActivateCurrency(adminContext(), &exchange.ActivateCurrencyRequest{Currency: string(models.RUB)})
+		require.NoError(t, err)
+	})
+
+	request := &exchange.ExchangeRateRequest{FromCurrency: string(models.USD), ToCurrency: string(models.RUB)}
+	before, err := exchangeClient.GetExchangeRateForOne(context.Background(), request)
+	require.NoError(t, err)
+
+	_, err = adminClient.DeactivateCurrency(adminContext(), &exchange.DeactivateCurrencyRequest{Currency: string(models.RUB)})
+	require.NoError(t, err)
+
+	_, err = exchangeClient.GetExchangeRateForOne(context.Background(), request)
+	require.Error(t, err)
+
+	resp, err := exchangeClient.GetExchangeRates(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+	require.NotContains(t, resp.Rates, string(models.RUB))
+
+	// deactivation keeps the rate, so activation brings the currency back with it
+	_, err = adminClient.ActivateCurrency(adminContext(), &exchange.ActivateCurrencyRequest{Currency: string(models.RUB)})
+	require.NoError(t, err)
+
+	after, err := exchangeClient.GetExchangeRateForOne(context.Background(), request)
+	require.NoError(t, err)
+	require.Equal(t, before.Rate, after.Rate)
+	require.Greater(t, after.Version, before.Version)
+
+	_, err = adminClient.DeactivateCurrency(adminContext(), &exchange.DeactivateCurrencyRequest{Currency: string(models.USD)})
+	requireCode(t, err, codes.InvalidArgument)
+}
@@ -0,0 +1,59 @@
+package integration
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"test-task/api/gen/grpc/exchange"
+	"test-task/exchanger/internal/models"
+	"testing"
+)
+
+func TestConvertBatch(t *testing.T) {
+
+	req := exchange.ConvertBatchRequest{Items: []*exchange.ConversionItem{
+		{FromCurrency: string(models.RUB), ToCurrency: string(models.EUR), Amount: "100"},
+		{FromCurrency: string(models.RUB), ToCurrency: string(models.USD), Amount: "1.5"},
+		{FromCurrency: string(models.USD), ToCurrency: string(models.USD), Amount: "10"},
+		{FromCurrency: string(models.USD), ToCurrency: string(models.EUR), Amount: "3.33"},
+	}}
+
+	resp, err := exchangeClient.ConvertBatch(context.Background(), &req)
+	require.NoError(t, err)
+	require.Len(t, resp.Results, len(req.Items))
+
+	expected := []string{"850", "15", "10", "2.83"}
+	for i, result := range resp.Results {
+		item := req.Items[i]
+		assert.Equal(t, item.FromCurrency, result.FromCurrency)
+		assert.Equal(t, item.ToCurrency, result.ToCurrency)
+		assert.Equal(t, item.Amount, result.Amount)
+		assertRate(t, decimal.RequireFromString(expected[i]), result.ConvertedAmount)
+	}
+
+	assertRate(t, rates[models.EUR].Div(rates[models.RUB]), resp.Results[0].Rate)
+	assertRate(t, rates[models.EUR], resp.Results[3].Rate)
+}
+
+func TestConvertBatch_InvalidRequest(t *testing.T) {
+
+	_, err := exchangeClient.ConvertBatch(context.Background(), &exchange.ConvertBatchRequest{})
+	requireCode(t, err, codes.InvalidArgument)
+
+	invalid := []*exchange.ConversionItem{
+		{FromCurrency: "ABC", ToCurrency: string(models.USD), Amount: "1"},
+		{FromCurrency: string(models.USD), ToCurrency: "ABC", Amount: "1"},
+		{FromCurrency: string(models.USD), ToCurrency: string(models.EUR), Amount: "abc"},
+		{FromCurrency: string(models.USD), ToCurrency: string(models.EUR), Amount: "-1"},
+	}
+	for _, item := range invalid {
+		req := exchange.ConvertBatchRequest{Items: []*exchange.ConversionItem{
+			{FromCurrency: string(models.USD), ToCurrency: string(models.EUR), Amount: "1"},
+			item,
+		}}
+		_, err = exchangeClient.ConvertBatch(context.Background(), &req)
+		requireCode(t, err, codes.InvalidArgument)
+	}
+}
@@ -0,0 +1,67 @@
+package integration
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"test-task/api/gen/grpc/exchange"
+	"test-task/exchanger/internal/models"
+	"testing"
+	"time"
+)
+
+func TestGetRateAt_Now(t *testing.T) {
+
+	req := exchange.ExchangeRateAtRequest{
+		FromCurrency: string(models.USD),
+		ToCurrency:   string(models.EUR),
+		At:           timestamppb.Now(),
+	}
+	resp, err := exchangeClient.GetExchangeRateAt(context.Background(), &req)
+	require.NoError(t, err)
+
+	assertRate(t, rates[models.Currency(req.ToCurrency)], resp.Rate)
+}
+
+func TestGetRateAt_BeforeHistory(t *testing.T) {
+
+	req := exchange.ExchangeRateAtRequest{
+		FromCurrency: string(models.USD),
+		ToCurrency:   string(models.EUR),
+		At:           timestamppb.New(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)),
+	}
+	_, err := exchangeClient.GetExchangeRateAt(context.Background(), &req)
+	require.Error(t, err)
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.NotFound, st.Code())
+}
+
+func TestGetRateAt_InvalidRequest(t *testing.T) {
+
+	req := exchange.ExchangeRateAtRequest{
+		FromCurrency: "tugrik",
+		ToCurrency:   string(models.EUR),
+		At:           timestamppb.Now(),
+	}
+	_, err := exchangeClient.GetExchangeRateAt(context.Background(), &req)
+	require.Error(t, err)
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+
+	req = exchange.ExchangeRateAtRequest{
+		FromCurrency: string(models.USD),
+		ToCurrency:   string(models.EUR),
+	}
+	_, err = exchangeClient.GetExchangeRateAt(context.Background(), &req)
+	require.Error(t, err)
+
+	st, ok = status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+}
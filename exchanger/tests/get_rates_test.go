@@ -2,6 +2,7 @@ package integration
 
 import (
 	"context"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
@@ -10,6 +11,7 @@ import (
 	"test-task/api/gen/grpc/exchange"
 	"test-task/exchanger/internal/models"
 	"testing"
+	"time"
 )
 
 func TestGetRates(t *testing.T) {
@@ -18,6 +20,12 @@ func TestGetRates(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Equal(t, convertRates(rates), ratesResponse.Rates)
+
+	// clients that predate decimal rates still get the doubles under the old field number
+	require.Len(t, ratesResponse.LegacyRates, len(rates))
+	for currency, rate := range rates {
+		assert.InDelta(t, rate.InexactFloat64(), ratesResponse.LegacyRates[string(currency)], 1e-9)
+	}
 }
 
 func TestGetRate_FromBaseCurrency(t *testing.T) {
@@ -29,7 +37,7 @@ func TestGetRate_FromBaseCurrency(t *testing.T) {
 	resp, err := exchangeClient.GetExchangeRateForOne(context.Background(), &req)
 	require.NoError(t, err)
 
-	assert.Equal(t, rates[models.Currency(req.ToCurrency)], resp.Rate)
+	assertRate(t, rates[models.Currency(req.ToCurrency)], resp.Rate)
 }
 
 func TestGetRate_ToBaseCurrency(t *testing.T) {
@@ -41,7 +49,7 @@ func TestGetRate_ToBaseCurrency(t *testing.T) {
 	resp, err := exchangeClient.GetExchangeRateForOne(context.Background(), &req)
 	require.NoError(t, err)
 
-	assert.Equal(t, 1/rates[models.Currency(req.FromCurrency)], resp.Rate)
+	assertRate(t, decimal.NewFromInt(1).Div(rates[models.Currency(req.FromCurrency)]), resp.Rate)
 }
 
 func TestGetRate_NotBaseCurrencies(t *testing.T) {
@@ -53,7 +61,7 @@ func TestGetRate_NotBaseCurrencies(t *testing.T) {
 	resp, err := exchangeClient.GetExchangeRateForOne(context.Background(), &req)
 	require.NoError(t, err)
 
-	assert.Equal(t, rates[models.Currency(req.FromCurrency)]/rates[models.Currency(req.ToCurrency)], resp.Rate)
+	assertRate(t, rates[models.Currency(req.ToCurrency)].Div(rates[models.Currency(req.FromCurrency)]), resp.Rate)
 }
 
 func TestGetRate_InvalidCurrency(t *testing.T) {
@@ -80,3 +88,68 @@ func TestGetRate_InvalidCurrency(t *testing.T) {
 	require.True(t, ok)
 	require.Equal(t, codes.InvalidArgument, st.Code())
 }
+
+func assertRate(t *testing.T, expected decimal.Decimal, actual string) {
+	rate, err := decimal.NewFromString(actual)
+	require.NoError(t, err)
+	assert.True(t, expected.Equal(rate), "expected %s, got %s", expected, actual)
+}
+
+func TestGetRates_Metadata(t *testing.T) {
+
+	restoreRates(t)
+
+	before, err := exchangeClient.GetExchangeRates(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.Equal(t, string(models.USD), before.BaseCurrency)
+	assert.Positive(t, before.Version)
+	require.NotNil(t, before.EffectiveAt)
+
+	one, err := exchangeClient.GetExchangeRateForOne(context.Background(), &exchange.ExchangeRateRequest{
+		FromCurrency: string(models.RUB),
+		ToCurrency:   string(models.EUR),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, before.BaseCurrency, one.BaseCurrency)
+	assert.Equal(t, before.Version, one.Version)
+	assert.True(t, before.EffectiveAt.AsTime().Equal(one.EffectiveAt.AsTime()))
+
+	_, err = adminClient.SetRate(adminContext(), &exchange.SetRateRequest{Currency: string(models.EUR), Rate: "0.9"})
+	require.NoError(t, err)
+
+	after, err := exchangeClient.GetExchangeRates(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.Greater(t, after.Version, before.Version)
+	assert.False(t, after.EffectiveAt.AsTime().Before(before.EffectiveAt.AsTime()))
+}
+
+func TestGetRate_BaseCurrencyChanged(t *testing.T) {
+
+	ctx := context.Background()
+	t.Cleanup(func() {
+		require.NoError(t, storage.ImportRates(ctx, rates, "test", time.Now(), time.Now()))
+	})
+
+	// the admin API keeps the base currency, so it is changed right in the DB
+	eurBased := map[models.Currency]decimal.Decimal{
+		models.EUR: decimal.NewFromInt(1),
+		models.USD: decimal.RequireFromString("1.25"),
+		models.RUB: decimal.RequireFromString("12.5"),
+	}
+	require.NoError(t, storage.ImportRates(ctx, eurBased, "test", time.Now(), time.Now()))
+
+	resp, err := exchangeClient.GetExchangeRateForOne(ctx, &exchange.ExchangeRateRequest{
+		FromCurrency: string(models.EUR),
+		ToCurrency:   string(models.RUB),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, string(models.EUR), resp.BaseCurrency)
+	assertRate(t, eurBased[models.RUB], resp.Rate)
+
+	resp, err = exchangeClient.GetExchangeRateForOne(ctx, &exchange.ExchangeRateRequest{
+		FromCurrency: string(models.USD),
+		ToCurrency:   string(models.EUR),
+	})
+	require.NoError(t, err)
+	assertRate(t, decimal.RequireFromString("0.8"), resp.Rate)
+}
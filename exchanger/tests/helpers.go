@@ -1,11 +1,22 @@
 package integration
 
-import "test-task/exchanger/internal/models"
+import (
+	"github.com/shopspring/decimal"
+	"test-task/exchanger/internal/models"
+)
 
-func convertRates(rates map[models.Currency]float64) map[string]float64 {
-	res := map[string]float64{}
+func convertRates(rates map[models.Currency]decimal.Decimal) map[string]string {
+	res := map[string]string{}
 	for k, v := range rates {
-		res[string(k)] = v
+		res[string(k)] = v.String()
+	}
+	return res
+}
+
+func rateStrings(rates map[models.Currency]decimal.Decimal) map[models.Currency]string {
+	res := map[models.Currency]string{}
+	for k, v := range rates {
+		res[k] = v.String()
 	}
 	return res
 }
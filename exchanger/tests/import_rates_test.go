@@ -0,0 +1,128 @@
+package integration
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"test-task/api/gen/grpc/exchange"
+	"test-task/exchanger/internal/models"
+	"test-task/exchanger/internal/providers"
+	"test-task/exchanger/internal/services"
+	"testing"
+	"time"
+)
+
+const ecbDaily = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2025-01-02">
+			<Cube currency="USD" rate="1.25"/>
+			<Cube currency="GBP" rate="0.83"/>
+			<Cube currency="RUB" rate="12.5"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func writeSource(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func importRates(t *testing.T, kind providers.Kind, source string) {
+
+	provider, err := providers.New(kind, source)
+	require.NoError(t, err)
+
+	err = services.NewRateImporter(provider, storage, time.Hour).Import(context.Background())
+	require.NoError(t, err)
+}
+
+func requireRates(t *testing.T, expected map[models.Currency]string) {
+
+	resp, err := exchangeClient.GetExchangeRates(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, resp.Rates, len(expected))
+
+	for currency, rate := range expected {
+		assertRate(t, decimal.RequireFromString(rate), resp.Rates[string(currency)])
+	}
+}
+
+func TestImportRates_CSV(t *testing.T) {
+
+	restoreRates(t)
+
+	path := writeSource(t, "rates.csv", "currency,rate\nUSD,1\nEUR,0.9\nRUB,0.2\n")
+	importRates(t, providers.CSV, path)
+
+	requireRates(t, map[models.Currency]string{models.USD: "1", models.EUR: "0.9", models.RUB: "0.2"})
+}
+
+func TestImportRates_ECB(t *testing.T) {
+
+	restoreRates(t)
+
+	path := writeSource(t, "eurofxref-daily.xml", ecbDaily)
+	importRates(t, providers.ECB, path)
+
+	// rebased from EUR onto USD, GBP is not a known currency
+	requireRates(t, map[models.Currency]string{models.USD: "1", models.EUR: "0.8", models.RUB: "10"})
+}
+
+func TestImportRates_EffectiveAt(t *testing.T) {
+
+	restoreRates(t)
+
+	// the rates take effect when the source says, not when they are imported
+	effectiveAt := time.Now()
+	time.Sleep(50 * time.Millisecond)
+	eur := map[models.Currency]decimal.Decimal{models.USD: decimal.NewFromInt(1), models.EUR: decimal.RequireFromString("0.9")}
+	require.NoError(t, storage.ImportRates(context.Background(), eur, "test", time.Now(), effectiveAt))
+
+	resp, err := exchangeClient.GetExchangeRateAt(context.Background(), &exchange.ExchangeRateAtRequest{
+		FromCurrency: string(models.USD),
+		ToCurrency:   string(models.EUR),
+		At:           timestamppb.New(effectiveAt),
+	})
+	require.NoError(t, err)
+	assertRate(t, decimal.RequireFromString("0.9"), resp.Rate)
+}
+
+func TestImportRates_JSON(t *testing.T) {
+
+	restoreRates(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"base": "RUB", "rates": {"USD": "0.01", "EUR": 0.008}}`))
+	}))
+	defer server.Close()
+
+	importRates(t, providers.JSON, server.URL)
+
+	requireRates(t, map[models.Currency]string{models.USD: "1", models.EUR: "0.8", models.RUB: "100"})
+}
+
+func TestImportRates_SourceUnavailable(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	provider, err := providers.New(providers.JSON, server.URL)
+	require.NoError(t, err)
+
+	err = services.NewRateImporter(provider, storage, time.Hour).Import(context.Background())
+	require.Error(t, err)
+
+	requireRates(t, rateStrings(rates))
+}
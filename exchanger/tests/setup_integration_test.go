@@ -3,6 +3,7 @@ package integration
 import (
 	"context"
 	"fmt"
+	"github.com/shopspring/decimal"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
@@ -18,6 +19,7 @@ import (
 	"test-task/exchanger/internal/config"
 	"test-task/exchanger/internal/models"
 	"test-task/exchanger/internal/services"
+	"test-task/exchanger/internal/storage/postgres"
 	mygrpc "test-task/exchanger/internal/transport/grpc"
 	"testing"
 	"time"
@@ -25,12 +27,14 @@ import (
 
 var cfg *config.Config
 var dbContainer testcontainers.Container
-var rates = map[models.Currency]float64{
-	models.USD: 1,
-	models.EUR: 0.85,
-	models.RUB: 0.1,
+var rates = map[models.Currency]decimal.Decimal{
+	models.USD: decimal.RequireFromString("1"),
+	models.EUR: decimal.RequireFromString("0.85"),
+	models.RUB: decimal.RequireFromString("0.1"),
 }
+var storage *postgres.Storage
 var exchangeClient exchange.ExchangeClient
+var adminClient exchange.ExchangeAdminClient
 
 func setupApp() error {
 
@@ -40,19 +44,34 @@ func setupApp() error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	storage, _, err := app.InitDB(cfg)
+	storage, _, err = app.InitDB(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
 
-	server := grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler()))
+	currencies := services.NewCurrencyService(storage, cfg.CurrenciesRefresh)
+	err = currencies.Load(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to load currencies: %w", err)
+	}
+
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.UnaryInterceptor(mygrpc.AdminAuthInterceptor(cfg.AdminToken)),
+	)
 	reflection.Register(server)
 
 	exchangeService := services.NewExchangeService(storage)
 	exchangeServer := mygrpc.NewExchangeServer(exchangeService)
+	go exchangeService.WatchRates(context.Background())
 
 	exchange.RegisterExchangeServer(server, exchangeServer)
 
+	adminService := services.NewAdminService(storage, currencies)
+	adminServer := mygrpc.NewAdminServer(adminService)
+
+	exchange.RegisterExchangeAdminServer(server, adminServer)
+
 	listener, err := net.Listen("tcp", ":"+cfg.Port)
 	if err != nil {
 		return fmt.Errorf("failed to create port listener: %w", err)
@@ -75,6 +94,7 @@ func setupApp() error {
 	}
 
 	exchangeClient = exchange.NewExchangeClient(conn)
+	adminClient = exchange.NewExchangeAdminClient(conn)
 	return nil
 }
 
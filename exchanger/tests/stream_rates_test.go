@@ -0,0 +1,33 @@
+package integration
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"test-task/api/gen/grpc/exchange"
+	"test-task/exchanger/internal/models"
+	"testing"
+	"time"
+)
+
+func TestStreamRates(t *testing.T) {
+
+	restoreRates(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := exchangeClient.StreamExchangeRates(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, convertRates(rates), resp.Rates)
+
+	_, err = adminClient.SetRate(adminContext(), &exchange.SetRateRequest{Currency: string(models.EUR), Rate: "0.95"})
+	require.NoError(t, err)
+
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "0.95", resp.Rates[string(models.EUR)])
+}
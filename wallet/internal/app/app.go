@@ -14,6 +14,7 @@ import (
 	"test-task/wallet/internal/services"
 	"test-task/wallet/internal/storage/postgres"
 	"test-task/wallet/internal/storage/redis"
+	"test-task/wallet/internal/storage/sqlite"
 	"test-task/wallet/internal/tracing"
 	"test-task/wallet/internal/transport/http"
 )
@@ -41,15 +42,12 @@ func New() (*App, error) {
 
 	initLogger(cfg)
 
-	storage, connector, err := InitDB(cfg)
+	storage, storageShutdown, err := InitStorage(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize database: %w", err)
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
-	shutdowns = append(shutdowns, shutdownTask{
-		name:     "db connection",
-		shutdown: connector.Close,
-	})
+	shutdowns = append(shutdowns, *storageShutdown)
 
 	tracer, err := tracing.InitTracer(cfg.ServiceName, cfg.OtelEndpoint)
 	if err != nil {
@@ -74,25 +72,60 @@ func New() (*App, error) {
 		shutdown: cache.Close,
 	})
 
+	currencies := services.NewCurrencyService(storage, cfg.CurrenciesRefresh)
+	if err = currencies.Load(context.Background()); err != nil {
+		return nil, err
+	}
+
 	exchanger, err := createExchangerClient(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create exchanger client: %w", err)
 	}
 
 	jwt, err := services.NewJwtService(services.JWTConfig{
-		SecretKey: cfg.JwtSecret,
-		Lifetime:  cfg.JwtLifetime,
-		Issuer:    cfg.JwtIssuer,
-		Audience:  cfg.JwtAudience,
-	})
+		Algorithm:        cfg.JwtAlgorithm,
+		Lifetime:         cfg.JwtLifetime,
+		RefreshLifetime:  cfg.RefreshTokenLifetime,
+		KeyRotation:      cfg.JwtKeyRotation,
+		KeyOverlap:       cfg.JwtKeyOverlap,
+		Issuer:           cfg.JwtIssuer,
+		Audience:         cfg.JwtAudience,
+		KeyEncryptionKey: cfg.JwtKeyEncryptionKey,
+	}, storage)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create jwt service: %w", err)
 	}
 
-	wallet := services.NewWalletService(storage, exchanger, cache)
-	auth := services.NewAuthService(jwt, storage)
+	if err = jwt.RotateKeys(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to load signing keys: %w", err)
+	}
 
-	server := startServer(cfg, auth, wallet)
+	wallet := services.NewWalletService(storage, exchanger, cache, services.WalletConfig{
+		QuoteLifetime: cfg.QuoteLifetime,
+		Fees:          cfg.ExchangeFees,
+		Limits:        cfg.Limits,
+	})
+	auth := services.NewAuthService(jwt, storage, cache)
+	admin := services.NewAdminService(storage, wallet, auth)
+
+	if err = admin.GrantAdmins(context.Background(), cfg.AdminUsers); err != nil {
+		return nil, fmt.Errorf("failed to grant admin roles: %w", err)
+	}
+
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	go wallet.WatchExchangeRates(watchCtx)
+	go currencies.Watch(watchCtx)
+	go jwt.WatchKeys(watchCtx)
+
+	shutdowns = append(shutdowns, shutdownTask{
+		name: "watchers",
+		shutdown: func(context.Context) error {
+			stopWatching()
+			return nil
+		},
+	})
+
+	server := startServer(cfg, jwt, auth, wallet, admin, cache, cache)
 
 	return &App{cfg: cfg, server: server, shutdowns: shutdowns}, nil
 }
@@ -144,6 +177,48 @@ func initLogger(cfg *config.Config) {
 	slog.SetDefault(logger)
 }
 
+// Storage is everything the services need from a storage.
+type Storage interface {
+	services.AccountsRepository
+	services.AuthRepository
+	services.AdminRepository
+	services.CurrenciesRepository
+	services.SigningKeyRepository
+}
+
+// InitStorage connects to the database chosen by the config and applies its migrations.
+func InitStorage(cfg *config.Config) (Storage, *shutdownTask, error) {
+
+	if cfg.DbDriver == config.SQLiteDriver {
+		storage, connector, err := InitSQLite(cfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to initialize sqlite database: %w", err)
+		}
+		slog.Info("using sqlite storage", "url", cfg.DbUrl)
+		return storage, &shutdownTask{name: "db connection", shutdown: connector.Close}, nil
+	}
+
+	storage, connector, err := InitDB(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	return storage, &shutdownTask{name: "db connection", shutdown: connector.Close}, nil
+}
+
+func InitSQLite(cfg *config.Config) (*sqlite.Storage, *sqlite.Connector, error) {
+	connector, err := sqlite.NewConnector(context.Background(), cfg.DbUrl)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize sqlite connector: %w", err)
+	}
+
+	err = sqlite.RunMigrations(connector.DB(), cfg.MigrationsPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	return sqlite.NewStorage(connector.DB()), connector, nil
+}
+
 func InitDB(cfg *config.Config) (*postgres.Storage, *postgres.Connector, error) {
 	connector, err := postgres.NewConnector(context.Background(), cfg.DbUrl)
 	if err != nil {
@@ -168,12 +243,14 @@ func createExchangerClient(cfg *config.Config) (*clients.ExchangerClient, error)
 	return clients.NewExchangerClient(cfg.ExchangerUrl)
 }
 
-func startServer(cfg *config.Config, auth http.AuthService, wallet http.WalletService) *echo.Echo {
+func startServer(cfg *config.Config, tokens http.TokenService, auth http.AuthService, wallet http.WalletService,
+	admin http.AdminService, idempotencyStore http.IdempotencyStore, revocationStore http.RevocationStore) *echo.Echo {
 
 	serverConfig := http.Config{
-		ServiceName:   cfg.ServiceName,
-		JwtSecret:     cfg.JwtSecret,
-		LaunchSwagger: cfg.Env == config.Development,
+		ServiceName:            cfg.ServiceName,
+		Tokens:                 tokens,
+		LaunchSwagger:          cfg.Env == config.Development,
+		IdempotencyKeyLifetime: cfg.IdempotencyKeyLifetime,
 	}
-	return http.NewServer(serverConfig, wallet, auth)
+	return http.NewServer(serverConfig, wallet, auth, admin, idempotencyStore, revocationStore)
 }
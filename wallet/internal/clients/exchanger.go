@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	_ "github.com/mbobakov/grpc-consul-resolver"
+	"github.com/shopspring/decimal"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -46,27 +47,79 @@ func NewExchangerClientWithConsul(consulAddress string) (*ExchangerClient, error
 	return &ExchangerClient{conn: conn, client: client}, nil
 }
 
-func (e *ExchangerClient) GetExchangeRates(ctx context.Context) (map[models.Currency]float64, error) {
+func (e *ExchangerClient) GetExchangeRates(ctx context.Context) (*models.ExchangeRates, error) {
 	resp, err := e.client.GetExchangeRates(ctx, &emptypb.Empty{})
 	if err != nil {
 		return nil, err
 	}
-	rates := make(map[models.Currency]float64)
-	for currency, rate := range resp.GetRates() {
-		rates[models.Currency(currency)] = rate
+	return parseRates(resp)
+}
+
+// StreamExchangeRates calls onUpdate with the full rate map every time the exchanger reports a change,
+// starting with the current rates. It blocks until ctx is done or the stream breaks.
+func (e *ExchangerClient) StreamExchangeRates(ctx context.Context,
+	onUpdate func(*models.ExchangeRates)) error {
+
+	stream, err := e.client.StreamExchangeRates(ctx, &emptypb.Empty{})
+	if err != nil {
+		return err
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		rates, err := parseRates(resp)
+		if err != nil {
+			return err
+		}
+		onUpdate(rates)
 	}
-	return rates, nil
 }
 
-func (e *ExchangerClient) GetExchangeRateForOne(ctx context.Context, from models.Currency, to models.Currency) (float64, error) {
+func (e *ExchangerClient) GetExchangeRateForOne(ctx context.Context, from models.Currency, to models.Currency) (decimal.Decimal, error) {
 	resp, err := e.client.GetExchangeRateForOne(ctx, &exchange.ExchangeRateRequest{
 		FromCurrency: string(from),
 		ToCurrency:   string(to),
 	})
 	if err != nil {
-		return 0, err
+		return decimal.Zero, err
+	}
+
+	// an exchanger that predates decimal rates only sends the double
+	if resp.GetRate() == "" {
+		return decimal.NewFromFloat(resp.GetLegacyRate()), nil
+	}
+
+	rate, err := decimal.NewFromString(resp.GetRate())
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid rate: %w", err)
+	}
+	return rate, nil
+}
+
+func parseRates(in *exchange.ExchangeRatesResponse) (*models.ExchangeRates, error) {
+	rates := models.ExchangeRates{
+		Rates:       make(map[models.Currency]decimal.Decimal),
+		Base:        models.Currency(in.GetBaseCurrency()),
+		Version:     in.GetVersion(),
+		EffectiveAt: in.GetEffectiveAt().AsTime(),
+	}
+	for currency, rateStr := range in.GetRates() {
+		rate, err := decimal.NewFromString(rateStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate for %s: %w", currency, err)
+		}
+		rates.Rates[models.Currency(currency)] = rate
+	}
+	if len(in.GetRates()) == 0 {
+		for currency, rate := range in.GetLegacyRates() {
+			rates.Rates[models.Currency(currency)] = decimal.NewFromFloat(rate)
+		}
 	}
-	return resp.GetRate(), nil
+	return &rates, nil
 }
 
 func (e *ExchangerClient) Close() {
@@ -1,12 +1,16 @@
 package config
 
 import (
+	"encoding/base64"
 	"flag"
 	"fmt"
 	"github.com/go-playground/validator/v10"
 	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
 	"os"
 	"strconv"
+	"strings"
+	"test-task/wallet/internal/domain/models"
 	"time"
 )
 
@@ -17,21 +21,39 @@ const (
 	Development Environment = "development"
 )
 
+type DbDriver string
+
+const (
+	PostgresDriver DbDriver = "postgres"
+	SQLiteDriver   DbDriver = "sqlite"
+)
+
 type Config struct {
-	Env            Environment
-	Port           string        `validate:"required"`
-	ServiceName    string        `validate:"required"`
-	JwtSecret      string        `validate:"required"`
-	JwtLifetime    time.Duration `validate:"required,gt=0"`
-	JwtIssuer      string        `validate:"required"`
-	JwtAudience    string        `validate:"required"`
-	ExchangerUrl   string
-	DbUrl          string `validate:"required"`
-	MigrationsPath string `validate:"required"`
-	RedisAddress   string `validate:"required"`
-	RedisPassword  string `validate:"required"`
-	OtelEndpoint   string `validate:"required"`
-	ConsulAddress  string
+	Env                    Environment
+	Port                   string        `validate:"required"`
+	ServiceName            string        `validate:"required"`
+	JwtAlgorithm           string        `validate:"oneof=RS256 EdDSA"`
+	JwtLifetime            time.Duration `validate:"required,gt=0"`
+	RefreshTokenLifetime   time.Duration `validate:"required,gt=0"`
+	JwtKeyRotation         time.Duration `validate:"required,gt=0"`
+	JwtKeyOverlap          time.Duration `validate:"required,gt=0"`
+	JwtIssuer              string        `validate:"required"`
+	JwtAudience            string        `validate:"required"`
+	JwtKeyEncryptionKey    []byte        `validate:"len=32"`
+	IdempotencyKeyLifetime time.Duration `validate:"required,gt=0"`
+	QuoteLifetime          time.Duration `validate:"required,gt=0"`
+	CurrenciesRefresh      time.Duration `validate:"required,gt=0"`
+	ExchangeFees           models.FeeSchedule
+	Limits                 models.Limits
+	AdminUsers             []string
+	ExchangerUrl           string
+	DbDriver               DbDriver `validate:"oneof=postgres sqlite"`
+	DbUrl                  string   `validate:"required"`
+	MigrationsPath         string   `validate:"required"`
+	RedisAddress           string   `validate:"required"`
+	RedisPassword          string   `validate:"required"`
+	OtelEndpoint           string   `validate:"required"`
+	ConsulAddress          string
 }
 
 var flagSet = false
@@ -49,21 +71,77 @@ func Get() (*Config, error) {
 		return nil, fmt.Errorf("failed to convert jwt lifetime: %w", err)
 	}
 
+	refreshTokenLifetime, err := strconv.Atoi(os.Getenv("REFRESH_TOKEN_LIFETIME"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert refresh token lifetime: %w", err)
+	}
+
+	jwtKeyRotation, err := strconv.Atoi(os.Getenv("JWT_KEY_ROTATION"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert jwt key rotation: %w", err)
+	}
+
+	jwtKeyOverlap, err := strconv.Atoi(os.Getenv("JWT_KEY_OVERLAP"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert jwt key overlap: %w", err)
+	}
+
+	jwtKeyEncryptionKey, err := base64.StdEncoding.DecodeString(os.Getenv("JWT_KEY_ENCRYPTION_KEY"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode jwt key encryption key: %w", err)
+	}
+
+	idempotencyKeyLifetime, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_KEY_LIFETIME"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert idempotency key lifetime: %w", err)
+	}
+
+	quoteLifetime, err := strconv.Atoi(os.Getenv("QUOTE_LIFETIME"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert quote lifetime: %w", err)
+	}
+
+	currenciesRefresh, err := strconv.Atoi(os.Getenv("CURRENCIES_REFRESH_INTERVAL"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert currencies refresh interval: %w", err)
+	}
+
+	exchangeFees, err := getFees()
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := getLimits()
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := Config{
-		Env:            getEnvironment(),
-		Port:           os.Getenv("PORT"),
-		ServiceName:    os.Getenv("SERVICE_NAME"),
-		JwtSecret:      os.Getenv("JWT_SECRET"),
-		JwtLifetime:    time.Duration(jwtLifetime) * time.Second,
-		JwtIssuer:      os.Getenv("JWT_ISSUER"),
-		JwtAudience:    os.Getenv("JWT_AUDIENCE"),
-		ExchangerUrl:   os.Getenv("EXCHANGER_URL"),
-		DbUrl:          os.Getenv("DB_URL"),
-		MigrationsPath: os.Getenv("MIGRATIONS_PATH"),
-		RedisAddress:   os.Getenv("REDIS_ADDRESS"),
-		RedisPassword:  os.Getenv("REDIS_PASSWORD"),
-		OtelEndpoint:   os.Getenv("OTEL_ENDPOINT"),
-		ConsulAddress:  os.Getenv("CONSUL_ADDRESS"),
+		Env:                    getEnvironment(),
+		Port:                   os.Getenv("PORT"),
+		ServiceName:            os.Getenv("SERVICE_NAME"),
+		JwtAlgorithm:           os.Getenv("JWT_ALGORITHM"),
+		JwtLifetime:            time.Duration(jwtLifetime) * time.Second,
+		RefreshTokenLifetime:   time.Duration(refreshTokenLifetime) * time.Second,
+		JwtKeyRotation:         time.Duration(jwtKeyRotation) * time.Second,
+		JwtKeyOverlap:          time.Duration(jwtKeyOverlap) * time.Second,
+		JwtIssuer:              os.Getenv("JWT_ISSUER"),
+		JwtAudience:            os.Getenv("JWT_AUDIENCE"),
+		JwtKeyEncryptionKey:    jwtKeyEncryptionKey,
+		IdempotencyKeyLifetime: time.Duration(idempotencyKeyLifetime) * time.Second,
+		QuoteLifetime:          time.Duration(quoteLifetime) * time.Second,
+		CurrenciesRefresh:      time.Duration(currenciesRefresh) * time.Second,
+		ExchangeFees:           exchangeFees,
+		Limits:                 limits,
+		AdminUsers:             splitList(os.Getenv("ADMIN_USERS")),
+		ExchangerUrl:           os.Getenv("EXCHANGER_URL"),
+		DbDriver:               getDbDriver(),
+		DbUrl:                  os.Getenv("DB_URL"),
+		MigrationsPath:         os.Getenv("MIGRATIONS_PATH"),
+		RedisAddress:           os.Getenv("REDIS_ADDRESS"),
+		RedisPassword:          os.Getenv("REDIS_PASSWORD"),
+		OtelEndpoint:           os.Getenv("OTEL_ENDPOINT"),
+		ConsulAddress:          os.Getenv("CONSUL_ADDRESS"),
 	}
 
 	validate := validator.New()
@@ -104,3 +182,49 @@ func getEnvironment() Environment {
 		return Development
 	}
 }
+
+func getDbDriver() DbDriver {
+	driver := DbDriver(os.Getenv("DB_DRIVER"))
+	if driver == "" {
+		return PostgresDriver
+	}
+	return driver
+}
+
+func parseAmount(value string) (decimal.Decimal, error) {
+	if value == "" {
+		return decimal.Zero, nil
+	}
+	res, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if res.IsNegative() {
+		return decimal.Zero, fmt.Errorf("amount %s is negative", value)
+	}
+	return res, nil
+}
+
+func splitList(value string) []string {
+	var res []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// isCurrencyCode only checks the format of a code: the currency registry is loaded from the database
+// after the config, and currencies may be added later.
+func isCurrencyCode(value string) bool {
+	if len(value) != 3 {
+		return false
+	}
+	for _, r := range value {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"github.com/shopspring/decimal"
+	"os"
+	"strings"
+	"test-task/wallet/internal/domain/models"
+)
+
+// getFees reads the exchange fee schedule. All variables are optional:
+//
+//	EXCHANGE_FEE_PERCENT=0.5
+//	EXCHANGE_FEE_FIXED=USD:0.10,EUR:0.10,RUB:10
+//	EXCHANGE_FEE_MIN=USD:0.50,EUR:0.50,RUB:50
+//	EXCHANGE_FEE_PAIRS=USD/RUB:1:0:0.50,RUB/USD:1:0:50 (from/to:percent:fixed:min)
+func getFees() (models.FeeSchedule, error) {
+
+	var fees models.FeeSchedule
+	var err error
+
+	if fees.Percent, err = parseAmount(os.Getenv("EXCHANGE_FEE_PERCENT")); err != nil {
+		return fees, fmt.Errorf("failed to parse exchange fee percent: %w", err)
+	}
+	if fees.Fixed, err = parseCurrencyAmounts(os.Getenv("EXCHANGE_FEE_FIXED")); err != nil {
+		return fees, fmt.Errorf("failed to parse fixed exchange fees: %w", err)
+	}
+	if fees.Min, err = parseCurrencyAmounts(os.Getenv("EXCHANGE_FEE_MIN")); err != nil {
+		return fees, fmt.Errorf("failed to parse minimum exchange fees: %w", err)
+	}
+	if fees.Pairs, err = parseFeePairs(os.Getenv("EXCHANGE_FEE_PAIRS")); err != nil {
+		return fees, fmt.Errorf("failed to parse exchange fee pairs: %w", err)
+	}
+
+	return fees, nil
+}
+
+func parseCurrencyAmounts(value string) (map[models.Currency]decimal.Decimal, error) {
+
+	res := make(map[models.Currency]decimal.Decimal)
+	for _, item := range splitList(value) {
+		currency, amount, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid item %q, expected CURRENCY:AMOUNT", item)
+		}
+		if !isCurrencyCode(currency) {
+			return nil, fmt.Errorf("invalid currency %q", currency)
+		}
+		fee, err := parseAmount(amount)
+		if err != nil {
+			return nil, err
+		}
+		res[models.Currency(currency)] = fee
+	}
+	return res, nil
+}
+
+func parseFeePairs(value string) (map[models.CurrencyPair]models.FeeRule, error) {
+
+	res := make(map[models.CurrencyPair]models.FeeRule)
+	for _, item := range splitList(value) {
+		parts := strings.Split(item, ":")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("invalid item %q, expected FROM/TO:PERCENT:FIXED:MIN", item)
+		}
+
+		from, to, ok := strings.Cut(parts[0], "/")
+		pair := models.CurrencyPair{From: models.Currency(from), To: models.Currency(to)}
+		if !ok || !isCurrencyCode(from) || !isCurrencyCode(to) || pair.From == pair.To {
+			return nil, fmt.Errorf("invalid currency pair %q", parts[0])
+		}
+
+		var rule models.FeeRule
+		var err error
+		if rule.Percent, err = parseAmount(parts[1]); err != nil {
+			return nil, err
+		}
+		if rule.Fixed, err = parseAmount(parts[2]); err != nil {
+			return nil, err
+		}
+		if rule.Min, err = parseAmount(parts[3]); err != nil {
+			return nil, err
+		}
+		res[pair] = rule
+	}
+	return res, nil
+}
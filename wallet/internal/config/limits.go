@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"test-task/wallet/internal/domain/models"
+)
+
+// getLimits reads the per-currency limits of withdrawals and exchanges. Both variables are optional,
+// a zero or missing value means "no limit":
+//
+//	WITHDRAW_LIMITS=USD:1000:5000:20000,EUR:1000:5000:20000 (currency:per operation:daily:monthly)
+//	EXCHANGE_LIMITS=RUB:0:500000:0
+func getLimits() (models.Limits, error) {
+
+	var limits models.Limits
+	var err error
+
+	if limits.Withdraw, err = parseLimits(os.Getenv("WITHDRAW_LIMITS")); err != nil {
+		return limits, fmt.Errorf("failed to parse withdraw limits: %w", err)
+	}
+	if limits.Exchange, err = parseLimits(os.Getenv("EXCHANGE_LIMITS")); err != nil {
+		return limits, fmt.Errorf("failed to parse exchange limits: %w", err)
+	}
+
+	return limits, nil
+}
+
+func parseLimits(value string) (map[models.Currency]models.Limit, error) {
+
+	res := make(map[models.Currency]models.Limit)
+	for _, item := range splitList(value) {
+		parts := strings.Split(item, ":")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("invalid item %q, expected CURRENCY:PER_OPERATION:DAILY:MONTHLY", item)
+		}
+
+		currency := models.Currency(parts[0])
+		if !isCurrencyCode(parts[0]) {
+			return nil, fmt.Errorf("invalid currency %q", parts[0])
+		}
+
+		var limit models.Limit
+		var err error
+		if limit.PerOperation, err = parseAmount(parts[1]); err != nil {
+			return nil, err
+		}
+		if limit.Daily, err = parseAmount(parts[2]); err != nil {
+			return nil, err
+		}
+		if limit.Monthly, err = parseAmount(parts[3]); err != nil {
+			return nil, err
+		}
+		res[currency] = limit
+	}
+	return res, nil
+}
@@ -9,3 +9,31 @@ var UserAlreadyExists = errors.New("user already exists")
 var InvalidAmount = errors.New("invalid amount")
 var InvalidCurrency = errors.New("invalid currency")
 var InsufficientFunds = errors.New("insufficient funds")
+var RecipientNotExists = errors.New("recipient not exists")
+var InvalidRecipient = errors.New("invalid recipient")
+var RecipientFrozen = errors.New("recipient frozen")
+var InvalidFilter = errors.New("invalid filter")
+var IdempotencyKeyReused = errors.New("idempotency key reused with a different request")
+var IdempotencyKeyInProgress = errors.New("request with this idempotency key is in progress")
+var InvalidIdempotencyKey = errors.New("invalid idempotency key")
+var QuoteNotExists = errors.New("quote not exists")
+var QuoteExpired = errors.New("quote expired")
+var LimitExceeded = errors.New("limit exceeded")
+var InvalidRefreshToken = errors.New("invalid refresh token")
+var RefreshTokenReused = errors.New("refresh token reused")
+var TokenRevoked = errors.New("token revoked")
+
+var TokenMissing = errors.New("token missing")
+var TokenMalformed = errors.New("token malformed")
+var TokenInvalidSignature = errors.New("token signature invalid")
+var TokenExpired = errors.New("token expired")
+var TokenNotValidYet = errors.New("token not valid yet")
+var TokenInvalidIssuer = errors.New("token issuer invalid")
+var TokenInvalidAudience = errors.New("token audience invalid")
+var TokenInvalidClaims = errors.New("token claims invalid")
+var Forbidden = errors.New("forbidden")
+var UserFrozen = errors.New("user frozen")
+var UserClosed = errors.New("user closed")
+var UserNotFound = errors.New("user not found")
+var InvalidUserID = errors.New("invalid user id")
+var ReasonRequired = errors.New("reason required")
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditFindUser         AuditAction = "find_user"
+	AuditViewUser         AuditAction = "view_user"
+	AuditViewBalance      AuditAction = "view_balance"
+	AuditViewTransactions AuditAction = "view_transactions"
+	AuditViewAuditLog     AuditAction = "view_audit_log"
+	AuditFreeze           AuditAction = "freeze"
+	AuditUnfreeze         AuditAction = "unfreeze"
+	AuditClose            AuditAction = "close"
+	AuditAdjust           AuditAction = "adjust"
+	AuditGrantRole        AuditAction = "grant_role"
+)
+
+// AuditEntry records an action of an admin. AdminID is nil for actions taken by the service itself,
+// UserID is nil when the action does not concern a single user.
+type AuditEntry struct {
+	ID        int64
+	AdminID   *int64
+	Action    AuditAction
+	UserID    *int64
+	Reason    string
+	Details   map[string]string
+	TraceID   string
+	CreatedAt time.Time
+}
+
+// AuditFilter selects audit entries, newest first. Zero fields match any entry.
+type AuditFilter struct {
+	AdminID  int64
+	UserID   int64
+	BeforeID int64
+	Limit    int
+}
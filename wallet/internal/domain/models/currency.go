@@ -1,18 +1,70 @@
 package models
 
+import (
+	"github.com/shopspring/decimal"
+	"sync/atomic"
+)
+
 type Currency string
 
+// Currencies that are always present in the registry. Any other currency is added as data.
 const (
 	USD Currency = "USD"
 	EUR Currency = "EUR"
 	RUB Currency = "RUB"
 )
 
-func (c Currency) IsValid() bool {
-	switch c {
-	case USD, EUR, RUB:
-		return true
-	default:
-		return false
+// CurrencyInfo describes a currency of the registry. Scale is the number of minor-unit digits
+// amounts in this currency are kept with.
+type CurrencyInfo struct {
+	Code    Currency
+	Name    string
+	Scale   int32
+	Enabled bool
+}
+
+var registry atomic.Pointer[map[Currency]CurrencyInfo]
+
+func init() {
+	SetCurrencies([]CurrencyInfo{
+		{Code: USD, Name: "US Dollar", Scale: 2, Enabled: true},
+		{Code: EUR, Name: "Euro", Scale: 2, Enabled: true},
+		{Code: RUB, Name: "Russian Ruble", Scale: 2, Enabled: true},
+	})
+}
+
+// SetCurrencies replaces the currency registry, normally with the currencies loaded from storage.
+func SetCurrencies(currencies []CurrencyInfo) {
+	res := make(map[Currency]CurrencyInfo, len(currencies))
+	for _, currency := range currencies {
+		res[currency.Code] = currency
 	}
+	registry.Store(&res)
+}
+
+// Currencies returns all the currencies of the registry, including disabled ones.
+func Currencies() map[Currency]CurrencyInfo {
+	return *registry.Load()
+}
+
+func (c Currency) Info() (CurrencyInfo, bool) {
+	info, ok := (*registry.Load())[c]
+	return info, ok
+}
+
+// IsValid reports whether the currency is in the registry and enabled.
+func (c Currency) IsValid() bool {
+	info, ok := c.Info()
+	return ok && info.Enabled
+}
+
+// Scale returns the number of minor-unit digits amounts in this currency are kept with.
+func (c Currency) Scale() int32 {
+	info, _ := c.Info()
+	return info.Scale
+}
+
+// IsValidAmount reports whether amount is positive and has no more fractional digits than the currency allows.
+func (c Currency) IsValidAmount(amount decimal.Decimal) bool {
+	return amount.IsPositive() && amount.Equal(amount.Truncate(c.Scale()))
 }
@@ -0,0 +1,48 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+var hundred = decimal.NewFromInt(100)
+
+// FeeRule describes the fee charged for an exchange, in the currency being sold.
+// The fee is Percent of the amount plus Fixed, but never less than Min.
+type FeeRule struct {
+	Percent decimal.Decimal
+	Fixed   decimal.Decimal
+	Min     decimal.Decimal
+}
+
+// Calculate returns the fee for amount, rounded up to the given scale.
+func (r FeeRule) Calculate(amount decimal.Decimal, scale int32) decimal.Decimal {
+	fee := amount.Mul(r.Percent).Div(hundred).Add(r.Fixed)
+	if fee.LessThan(r.Min) {
+		fee = r.Min
+	}
+	return fee.RoundUp(scale)
+}
+
+type CurrencyPair struct {
+	From Currency
+	To   Currency
+}
+
+// FeeSchedule holds the exchange fees. Fixed and minimum fees are set per source currency,
+// Pairs override the whole rule for specific exchange directions. The zero value charges no fees.
+type FeeSchedule struct {
+	Percent decimal.Decimal
+	Fixed   map[Currency]decimal.Decimal
+	Min     map[Currency]decimal.Decimal
+	Pairs   map[CurrencyPair]FeeRule
+}
+
+func (s FeeSchedule) Rule(from Currency, to Currency) FeeRule {
+	if rule, ok := s.Pairs[CurrencyPair{From: from, To: to}]; ok {
+		return rule
+	}
+	return FeeRule{Percent: s.Percent, Fixed: s.Fixed[from], Min: s.Min[from]}
+}
+
+// Fee returns the fee for exchanging amount of from to to, in the from currency.
+func (s FeeSchedule) Fee(from Currency, to Currency, amount decimal.Decimal) decimal.Decimal {
+	return s.Rule(from, to).Calculate(amount, from.Scale())
+}
@@ -0,0 +1,13 @@
+package models
+
+// IdempotencyRecord is what is remembered about a request sent with an Idempotency-Key.
+// A zero StatusCode means the original request is still being processed.
+type IdempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+func (r *IdempotencyRecord) IsCompleted() bool {
+	return r.StatusCode != 0
+}
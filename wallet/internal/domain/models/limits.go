@@ -0,0 +1,42 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// Limit restricts how much a user can debit in one currency. Zero values mean "no limit".
+type Limit struct {
+	PerOperation decimal.Decimal
+	Daily        decimal.Decimal
+	Monthly      decimal.Decimal
+}
+
+func (l Limit) AllowsOperation(amount decimal.Decimal) bool {
+	return l.PerOperation.IsZero() || amount.LessThanOrEqual(l.PerOperation)
+}
+
+// AllowsVolume reports whether the debited volumes of the current day and month, including
+// the operation being checked, are within the limit.
+func (l Limit) AllowsVolume(daily decimal.Decimal, monthly decimal.Decimal) bool {
+	return (l.Daily.IsZero() || daily.LessThanOrEqual(l.Daily)) &&
+		(l.Monthly.IsZero() || monthly.LessThanOrEqual(l.Monthly))
+}
+
+func (l Limit) HasVolume() bool {
+	return !l.Daily.IsZero() || !l.Monthly.IsZero()
+}
+
+// Limits holds the per-currency limits of withdrawals and exchanges. The zero value does not limit anything.
+type Limits struct {
+	Withdraw map[Currency]Limit
+	Exchange map[Currency]Limit
+}
+
+func (l Limits) For(transactionType TransactionType, currency Currency) Limit {
+	switch transactionType {
+	case WithdrawTransaction:
+		return l.Withdraw[currency]
+	case ExchangeTransaction:
+		return l.Exchange[currency]
+	default:
+		return Limit{}
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Principal is the caller authenticated by an access token.
+type Principal struct {
+	UserID    string
+	SessionID string
+	Roles     []Role
+	TokenID   string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+func (p *Principal) HasRole(role Role) bool {
+	return slices.Contains(p.Roles, role)
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// Quote locks an exchange rate for a user until ExpiresAt.
+type Quote struct {
+	ID              string          `json:"id"`
+	UserID          string          `json:"user_id"`
+	From            Currency        `json:"from"`
+	To              Currency        `json:"to"`
+	Amount          decimal.Decimal `json:"amount"`
+	ExchangedAmount decimal.Decimal `json:"exchanged_amount"`
+	Fee             decimal.Decimal `json:"fee"`
+	Rate            decimal.Decimal `json:"rate"`
+	ExpiresAt       time.Time       `json:"expires_at"`
+}
+
+func (q *Quote) IsExpired(now time.Time) bool {
+	return !now.Before(q.ExpiresAt)
+}
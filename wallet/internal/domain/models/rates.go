@@ -0,0 +1,15 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// ExchangeRates are the rates of all currencies relative to Base. Version is the version of the rates
+// in the exchanger, it grows with every change, and EffectiveAt is the time this version took effect.
+type ExchangeRates struct {
+	Rates       map[Currency]decimal.Decimal
+	Base        Currency
+	Version     int64
+	EffectiveAt time.Time
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"crypto"
+	"time"
+)
+
+// SigningKey is a key the wallet signs access tokens with. PrivateKey is PKCS #8 encoded and sealed with
+// the key encryption key: the nonce followed by the AES-GCM ciphertext.
+type SigningKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey []byte
+	CreatedAt  time.Time
+}
+
+// PublicKey verifies the tokens signed with the signing key of the same ID.
+type PublicKey struct {
+	ID        string
+	Algorithm string
+	Key       crypto.PublicKey
+}
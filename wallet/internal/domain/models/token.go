@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// RefreshToken is a stored refresh token: only the hash of the opaque token is kept. Every token issued
+// by rotating another one shares its family, so that a reused token can revoke all of them.
+type RefreshToken struct {
+	UserID    int64
+	FamilyID  string
+	Hash      []byte
+	ExpiresAt time.Time
+}
+
+// Tokens are issued on login and on every refresh.
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type TransactionType string
+
+const (
+	DepositTransaction  TransactionType = "deposit"
+	WithdrawTransaction TransactionType = "withdraw"
+	ExchangeTransaction TransactionType = "exchange"
+	TransferTransaction TransactionType = "transfer"
+	// AdjustmentTransaction is a manual correction made by an admin
+	AdjustmentTransaction TransactionType = "adjustment"
+)
+
+func (t TransactionType) IsValid() bool {
+	switch t {
+	case DepositTransaction, WithdrawTransaction, ExchangeTransaction, TransferTransaction, AdjustmentTransaction:
+		return true
+	default:
+		return false
+	}
+}
+
+// Transaction is a single ledger entry. Amount is signed: positive for credits, negative for debits.
+type Transaction struct {
+	ID             int64
+	UserID         int64
+	Type           TransactionType
+	Currency       Currency
+	Amount         decimal.Decimal
+	BalanceAfter   decimal.Decimal
+	CounterpartyID *int64
+	Rate           *decimal.Decimal // rate applied between the legs of a cross-currency operation
+	Fee            *decimal.Decimal // fee included in Amount and booked to the revenue account
+	TraceID        string
+	CreatedAt      time.Time
+}
+
+// TransactionFilter narrows down a user's transaction history. Zero values mean "no restriction".
+// BeforeID is the pagination cursor: only transactions with a smaller id are returned.
+type TransactionFilter struct {
+	Currency Currency
+	Type     TransactionType
+	From     time.Time
+	To       time.Time
+	BeforeID int64
+	Limit    int
+}
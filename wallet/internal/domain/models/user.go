@@ -1,8 +1,51 @@
 package models
 
+import "slices"
+
+type Role string
+
+const (
+	RoleUser  Role = "user"
+	RoleAdmin Role = "admin"
+)
+
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleUser, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+// UserStatus is where the user is in the account lifecycle. Frozen users can read their wallet but not move
+// money, closed users can't log in.
+type UserStatus string
+
+const (
+	UserActive UserStatus = "active"
+	UserFrozen UserStatus = "frozen"
+	UserClosed UserStatus = "closed"
+)
+
+func (s UserStatus) IsValid() bool {
+	switch s {
+	case UserActive, UserFrozen, UserClosed:
+		return true
+	default:
+		return false
+	}
+}
+
 type User struct {
 	ID       int64
 	Name     string
 	Password []byte
 	Email    string
+	Roles    []Role
+	Status   UserStatus
+}
+
+func (u *User) HasRole(role Role) bool {
+	return slices.Contains(u.Roles, role)
 }
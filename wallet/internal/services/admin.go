@@ -0,0 +1,318 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"log/slog"
+	"strconv"
+	"strings"
+	errs "test-task/wallet/internal/domain/errors"
+	"test-task/wallet/internal/domain/models"
+	"test-task/wallet/internal/tracing"
+)
+
+type AdminRepository interface {
+	GetUserByID(ctx context.Context, id int64) (*models.User, error)
+	GetUserByName(ctx context.Context, name string) (*models.User, error)
+	GetUserByNameOrEmail(ctx context.Context, login string) (*models.User, error)
+	AddUserRole(ctx context.Context, userID int64, role models.Role, entry *models.AuditEntry) error
+	SetUserStatus(ctx context.Context, userID int64, status models.UserStatus, entry *models.AuditEntry) error
+	AdjustAccountAmountWithBalance(ctx context.Context, userID string, currency models.Currency,
+		delta decimal.Decimal, entry *models.AuditEntry) (map[models.Currency]decimal.Decimal, error)
+	AddAuditEntry(ctx context.Context, entry *models.AuditEntry) error
+	GetAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+// AuditPage is one page of the audit log, newest first. NextCursor is zero when there are no more entries.
+type AuditPage struct {
+	Entries    []models.AuditEntry
+	NextCursor int64
+}
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 100
+)
+
+// AdminService is the operator surface of the wallet. Every action, reads included, is written to the
+// audit log before it takes effect; if the entry can't be written, the action fails.
+type AdminService struct {
+	repo   AdminRepository
+	wallet *WalletService
+	auth   *AuthService
+}
+
+func NewAdminService(repo AdminRepository, wallet *WalletService, auth *AuthService) *AdminService {
+	return &AdminService{repo: repo, wallet: wallet, auth: auth}
+}
+
+// GrantAdmins gives the admin role to the named users. It bootstraps the first admins at startup,
+// so users that don't exist yet are skipped.
+func (s *AdminService) GrantAdmins(ctx context.Context, names []string) error {
+
+	for _, name := range names {
+		user, err := s.repo.GetUserByName(ctx, name)
+		if err != nil {
+			if errors.Is(err, errs.UserNotExists) {
+				slog.Warn("admin user does not exist", "name", name)
+				continue
+			}
+			return fmt.Errorf("failed to get user by name: %w", err)
+		}
+		if user.HasRole(models.RoleAdmin) {
+			continue
+		}
+
+		entry := &models.AuditEntry{
+			Action:  models.AuditGrantRole,
+			UserID:  &user.ID,
+			Details: map[string]string{"role": string(models.RoleAdmin)},
+		}
+		if err = s.repo.AddUserRole(ctx, user.ID, models.RoleAdmin, entry); err != nil {
+			return fmt.Errorf("failed to grant admin role: %w", err)
+		}
+		slog.Info("granted admin role", "name", name)
+	}
+	return nil
+}
+
+// FindUser looks a user up by name or email.
+func (s *AdminService) FindUser(ctx context.Context, adminID string, login string) (*models.User, error) {
+
+	ctx, span := tracing.GetTracer().Start(ctx, "FindUser")
+	defer span.End()
+
+	user, err := s.repo.GetUserByNameOrEmail(ctx, login)
+	if err != nil && !errors.Is(err, errs.UserNotExists) {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	entry, entryErr := newAuditEntry(adminID, models.AuditFindUser, nil, "")
+	if entryErr != nil {
+		return nil, entryErr
+	}
+	entry.Details["login"] = login
+	if user != nil {
+		entry.UserID = &user.ID
+	}
+	if entryErr = s.repo.AddAuditEntry(ctx, entry); entryErr != nil {
+		return nil, entryErr
+	}
+
+	if err != nil {
+		return nil, errs.UserNotFound
+	}
+	return user, nil
+}
+
+func (s *AdminService) GetUser(ctx context.Context, adminID string, userID int64) (*models.User, error) {
+
+	ctx, span := tracing.GetTracer().Start(ctx, "GetUser")
+	defer span.End()
+
+	return s.audit(ctx, adminID, models.AuditViewUser, userID)
+}
+
+func (s *AdminService) GetBalance(ctx context.Context, adminID string, userID int64) (*BalanceInfo, error) {
+
+	ctx, span := tracing.GetTracer().Start(ctx, "AdminGetBalance")
+	defer span.End()
+
+	if _, err := s.audit(ctx, adminID, models.AuditViewBalance, userID); err != nil {
+		return nil, err
+	}
+	return s.wallet.GetBalance(ctx, strconv.FormatInt(userID, 10))
+}
+
+func (s *AdminService) GetTransactions(ctx context.Context, adminID string, userID int64,
+	filter models.TransactionFilter) (*TransactionsPage, error) {
+
+	ctx, span := tracing.GetTracer().Start(ctx, "AdminGetTransactions")
+	defer span.End()
+
+	if _, err := s.audit(ctx, adminID, models.AuditViewTransactions, userID); err != nil {
+		return nil, err
+	}
+	return s.wallet.GetTransactions(ctx, strconv.FormatInt(userID, 10), filter)
+}
+
+// Freeze stops the user from moving money. The user can still log in and read the wallet.
+func (s *AdminService) Freeze(ctx context.Context, adminID string, userID int64, reason string) error {
+
+	ctx, span := tracing.GetTracer().Start(ctx, "Freeze")
+	defer span.End()
+
+	return s.setStatus(ctx, adminID, userID, models.UserFrozen, models.AuditFreeze, reason)
+}
+
+func (s *AdminService) Unfreeze(ctx context.Context, adminID string, userID int64, reason string) error {
+
+	ctx, span := tracing.GetTracer().Start(ctx, "Unfreeze")
+	defer span.End()
+
+	return s.setStatus(ctx, adminID, userID, models.UserActive, models.AuditUnfreeze, reason)
+}
+
+// Close stops the user from logging in and ends all of their sessions. Closing can't be undone, but it can
+// be repeated: a closed user is logged out again, so a close whose logout failed is retried safely.
+func (s *AdminService) Close(ctx context.Context, adminID string, userID int64, reason string) error {
+
+	ctx, span := tracing.GetTracer().Start(ctx, "Close")
+	defer span.End()
+
+	err := s.setStatus(ctx, adminID, userID, models.UserClosed, models.AuditClose, reason)
+	if err != nil && !errors.Is(err, errs.UserClosed) {
+		return err
+	}
+	return s.auth.LogoutAll(ctx, strconv.FormatInt(userID, 10))
+}
+
+func (s *AdminService) setStatus(ctx context.Context, adminID string, userID int64, status models.UserStatus,
+	action models.AuditAction, reason string) error {
+
+	if strings.TrimSpace(reason) == "" {
+		return errs.ReasonRequired
+	}
+
+	entry, err := newAuditEntry(adminID, action, &userID, reason)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.SetUserStatus(ctx, userID, status, entry)
+	if err != nil {
+		if errors.Is(err, errs.UserNotExists) {
+			return errs.UserNotFound
+		}
+		return fmt.Errorf("failed to set user status: %w", err)
+	}
+	return nil
+}
+
+// Adjust corrects the balance of the user by a signed amount. The adjustment is recorded as a transaction
+// of its own, and a debit may not overdraw the account.
+func (s *AdminService) Adjust(ctx context.Context, adminID string, userID int64, currency models.Currency,
+	amount decimal.Decimal, reason string) (*BalanceInfo, error) {
+
+	ctx, span := tracing.GetTracer().Start(ctx, "Adjust")
+	defer span.End()
+
+	if strings.TrimSpace(reason) == "" {
+		return nil, errs.ReasonRequired
+	}
+
+	if !currency.IsValid() {
+		return nil, errs.InvalidCurrency
+	}
+
+	if !currency.IsValidAmount(amount.Abs()) {
+		return nil, errs.InvalidAmount
+	}
+
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	entry, err := newAuditEntry(adminID, models.AuditAdjust, &userID, reason)
+	if err != nil {
+		return nil, err
+	}
+	entry.Details["currency"] = string(currency)
+	entry.Details["amount"] = amount.String()
+
+	balance, err := s.repo.AdjustAccountAmountWithBalance(ctx, strconv.FormatInt(userID, 10), currency,
+		amount, entry)
+	if err != nil {
+		return nil, err
+	}
+	return &BalanceInfo{Accounts: balance}, nil
+}
+
+func (s *AdminService) GetAuditLog(ctx context.Context, adminID string, filter models.AuditFilter) (*AuditPage, error) {
+
+	ctx, span := tracing.GetTracer().Start(ctx, "GetAuditLog")
+	defer span.End()
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxAuditLimit || filter.BeforeID < 0 {
+		return nil, errs.InvalidFilter
+	}
+
+	entry, err := newAuditEntry(adminID, models.AuditViewAuditLog, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if filter.AdminID != 0 {
+		entry.Details["admin_id"] = strconv.FormatInt(filter.AdminID, 10)
+	}
+	if filter.UserID != 0 {
+		entry.Details["user_id"] = strconv.FormatInt(filter.UserID, 10)
+	}
+	if err = s.repo.AddAuditEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	limit := filter.Limit
+	filter.Limit++ // one extra row tells whether there is a next page
+
+	entries, err := s.repo.GetAuditEntries(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AuditPage{Entries: entries}
+	if len(entries) > limit {
+		page.Entries = entries[:limit]
+		page.NextCursor = entries[limit-1].ID
+	}
+	return page, nil
+}
+
+// audit records a read of the data of an existing user and returns the user.
+func (s *AdminService) audit(ctx context.Context, adminID string, action models.AuditAction,
+	userID int64) (*models.User, error) {
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := newAuditEntry(adminID, action, &userID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repo.AddAuditEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *AdminService) getUser(ctx context.Context, userID int64) (*models.User, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, errs.UserNotExists) {
+			return nil, errs.UserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user by id: %w", err)
+	}
+	return user, nil
+}
+
+func newAuditEntry(adminID string, action models.AuditAction, userID *int64, reason string) (*models.AuditEntry, error) {
+	id, err := strconv.ParseInt(adminID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid admin id %q: %w", adminID, err)
+	}
+	return &models.AuditEntry{
+		AdminID: &id,
+		Action:  action,
+		UserID:  userID,
+		Reason:  strings.TrimSpace(reason),
+		Details: map[string]string{},
+	}, nil
+}
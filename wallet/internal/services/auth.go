@@ -4,24 +4,38 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"log/slog"
 	"strconv"
 	errs "test-task/wallet/internal/domain/errors"
 	"test-task/wallet/internal/domain/models"
+	"time"
 )
 
 type AuthRepository interface {
 	AddUser(ctx context.Context, name string, password []byte, email string) error
 	GetUserByName(ctx context.Context, name string) (*models.User, error)
+	GetUserByID(ctx context.Context, id int64) (*models.User, error)
+	AddRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	RotateRefreshToken(ctx context.Context, hash []byte, next *models.RefreshToken) error
+	RevokeRefreshTokenFamily(ctx context.Context, userID string, hash []byte) error
+	RevokeUserRefreshTokens(ctx context.Context, userID string) error
+}
+
+type RevocationStore interface {
+	RevokeToken(ctx context.Context, id string, expiration time.Duration) error
+	RevokeUserTokens(ctx context.Context, userID string, issuedBefore time.Time, expiration time.Duration) error
 }
 
 type AuthService struct {
-	jwt  *JwtService
-	repo AuthRepository
+	jwt         *JwtService
+	repo        AuthRepository
+	revocations RevocationStore
 }
 
-func NewAuthService(jwt *JwtService, repo AuthRepository) *AuthService {
-	return &AuthService{jwt: jwt, repo: repo}
+func NewAuthService(jwt *JwtService, repo AuthRepository, revocations RevocationStore) *AuthService {
+	return &AuthService{jwt: jwt, repo: repo, revocations: revocations}
 }
 
 func (a *AuthService) Register(ctx context.Context, name, password, email string) error {
@@ -32,24 +46,109 @@ func (a *AuthService) Register(ctx context.Context, name, password, email string
 	return a.repo.AddUser(ctx, name, hashedPassword, email)
 }
 
-func (a *AuthService) Login(ctx context.Context, name, password string) (string, error) {
+// Login checks the password and issues an access token with a refresh token, which starts a new token family.
+// Closed users can't log in; frozen ones can, to read their wallet.
+func (a *AuthService) Login(ctx context.Context, name, password string) (*models.Tokens, error) {
 
 	user, err := a.repo.GetUserByName(ctx, name)
 	if err != nil {
 		if errors.Is(err, errs.UserNotExists) {
-			return "", err
+			return nil, err
 		}
-		return "", fmt.Errorf("failed to get user by name: %w", err)
+		return nil, fmt.Errorf("failed to get user by name: %w", err)
 	}
 
 	err = bcrypt.CompareHashAndPassword(user.Password, []byte(password))
 	if err != nil {
-		return "", errs.WrongPassword
+		return nil, errs.WrongPassword
+	}
+
+	if user.Status == models.UserClosed {
+		return nil, errs.UserClosed
+	}
+
+	refreshToken, stored, err := a.jwt.CreateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	stored.UserID = user.ID
+	stored.FamilyID = uuid.NewString()
+
+	if err = a.repo.AddRefreshToken(ctx, stored); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return a.createTokens(user, stored.FamilyID, refreshToken)
+}
+
+// Refresh exchanges a refresh token for a new pair. The old refresh token can't be used again: if it is,
+// the whole family is revoked, because either the user or an attacker holds a stolen token.
+func (a *AuthService) Refresh(ctx context.Context, refreshToken string) (*models.Tokens, error) {
+
+	nextToken, next, err := a.jwt.CreateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.repo.RotateRefreshToken(ctx, a.jwt.HashRefreshToken(refreshToken), next)
+	if err != nil {
+		if errors.Is(err, errs.RefreshTokenReused) {
+			slog.Warn("refresh token reused, token family revoked", "userId", next.UserID, "familyId", next.FamilyID)
+			return nil, err
+		}
+		if errors.Is(err, errs.InvalidRefreshToken) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
 	}
 
-	token, err := a.jwt.CreateToken(map[string]string{"id": strconv.FormatInt(user.ID, 10)})
+	// the user is read again, so that changed roles apply from the next refresh on
+	user, err := a.repo.GetUserByID(ctx, next.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by id: %w", err)
+	}
+	if user.Status == models.UserClosed {
+		return nil, errs.UserClosed
+	}
+
+	return a.createTokens(user, next.FamilyID, nextToken)
+}
+
+// Logout revokes the access token until it expires. The session ends only when its refresh token
+// is passed as well, otherwise the refresh token can still be used to get a new access token.
+func (a *AuthService) Logout(ctx context.Context, userID, tokenID string, expiresAt time.Time,
+	refreshToken string) error {
+
+	err := a.revocations.RevokeToken(ctx, tokenID, time.Until(expiresAt))
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+	return a.repo.RevokeRefreshTokenFamily(ctx, userID, a.jwt.HashRefreshToken(refreshToken))
+}
+
+// LogoutAll ends every session of the user: all access tokens issued so far and all refresh tokens are revoked.
+func (a *AuthService) LogoutAll(ctx context.Context, userID string) error {
+
+	err := a.revocations.RevokeUserTokens(ctx, userID, time.Now(), a.jwt.lifetime)
+	if err != nil {
+		return fmt.Errorf("failed to revoke tokens: %w", err)
+	}
+	return a.repo.RevokeUserRefreshTokens(ctx, userID)
+}
+
+// createTokens issues an access token for the session, which is the family of the refresh token.
+func (a *AuthService) createTokens(user *models.User, sessionID string, refreshToken string) (*models.Tokens, error) {
+	subject := TokenSubject{UserID: strconv.FormatInt(user.ID, 10), SessionID: sessionID}
+	for _, role := range user.Roles {
+		subject.Roles = append(subject.Roles, string(role))
+	}
+	token, err := a.jwt.CreateToken(subject, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create token: %w", err)
+		return nil, fmt.Errorf("failed to create token: %w", err)
 	}
-	return token, nil
+	return &models.Tokens{AccessToken: token, RefreshToken: refreshToken}, nil
 }
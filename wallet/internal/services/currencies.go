@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"test-task/wallet/internal/domain/models"
+	"test-task/wallet/internal/tracing"
+	"time"
+)
+
+type CurrenciesRepository interface {
+	GetCurrencies(ctx context.Context) ([]models.CurrencyInfo, error)
+}
+
+// CurrencyService keeps the currency registry of models in sync with the currencies table.
+type CurrencyService struct {
+	repository      CurrenciesRepository
+	refreshInterval time.Duration
+}
+
+func NewCurrencyService(repository CurrenciesRepository, refreshInterval time.Duration) *CurrencyService {
+	return &CurrencyService{repository: repository, refreshInterval: refreshInterval}
+}
+
+func (c *CurrencyService) Load(ctx context.Context) error {
+
+	ctx, span := tracing.GetTracer().Start(ctx, "LoadCurrencies")
+	defer span.End()
+
+	currencies, err := c.repository.GetCurrencies(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load currencies: %w", err)
+	}
+	if len(currencies) == 0 {
+		return fmt.Errorf("no currencies found")
+	}
+
+	models.SetCurrencies(currencies)
+	return nil
+}
+
+// Watch reloads the registry every refresh interval until ctx is done.
+func (c *CurrencyService) Watch(ctx context.Context) {
+
+	ticker := time.NewTicker(c.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Load(ctx); err != nil {
+				slog.Error("failed to refresh currencies", "error", err)
+			}
+		}
+	}
+}
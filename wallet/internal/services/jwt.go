@@ -1,59 +1,128 @@
 package services
 
 import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"github.com/google/uuid"
+	"sync"
+	errs "test-task/wallet/internal/domain/errors"
+	"test-task/wallet/internal/domain/models"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+func init() {
+	// token times are kept with milliseconds, so that revoking all the tokens of a user doesn't revoke
+	// the ones issued later within the same second
+	jwt.TimePrecision = time.Millisecond
+}
+
 type JWTConfig struct {
-	SecretKey string
-	Lifetime  time.Duration
-	Issuer    string
-	Audience  string
+	Algorithm       string
+	Lifetime        time.Duration
+	RefreshLifetime time.Duration
+	KeyRotation     time.Duration
+	KeyOverlap      time.Duration
+	Issuer          string
+	Audience        string
+	// KeyEncryptionKey encrypts the private signing keys in the key repository, see KeyEncryptionKeySize
+	KeyEncryptionKey []byte
 }
 
 type CustomClaims struct {
 	jwt.RegisteredClaims
+	SessionID   string            `json:"sid,omitempty"`
+	Roles       []string          `json:"roles,omitempty"`
 	ExtraClaims map[string]string `json:"extra,omitempty"`
 }
 
+// TokenSubject is who an access token is issued to: the user goes to the sub claim,
+// the session is the refresh token family the token was issued with.
+type TokenSubject struct {
+	UserID    string
+	SessionID string
+	Roles     []string
+}
+
+// JwtService signs access tokens with asymmetric keys that are rotated on schedule, see RotateKeys.
+// Keys must be loaded with RotateKeys before the first token is created.
 type JwtService struct {
-	secretKey          string
+	algorithm          string
 	issuer             string
 	audience           string
 	lifetime           time.Duration
-	signingMethod      jwt.SigningMethod
+	keyRotation        time.Duration
+	keyOverlap         time.Duration
+	keyRepository      SigningKeyRepository
+	keyCipher          cipher.AEAD
+	now                func() time.Time
+	mu                 sync.RWMutex
+	keys               []*signingKey // from oldest to newest
 	refreshTokenLength int
+	refreshLifetime    time.Duration
 }
 
-func NewJwtService(cfg JWTConfig) (*JwtService, error) {
+func NewJwtService(cfg JWTConfig, keyRepository SigningKeyRepository) (*JwtService, error) {
+
+	if cfg.Algorithm != AlgorithmRS256 && cfg.Algorithm != AlgorithmEdDSA {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", cfg.Algorithm)
+	}
+	if cfg.KeyOverlap < cfg.Lifetime || cfg.KeyOverlap <= keyRefreshInterval {
+		return nil, fmt.Errorf("key overlap must cover the token lifetime and be longer than %s", keyRefreshInterval)
+	}
+	if cfg.KeyRotation <= cfg.KeyOverlap {
+		return nil, fmt.Errorf("key rotation must be longer than key overlap")
+	}
+
+	keyCipher, err := newKeyCipher(cfg.KeyEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
 
 	return &JwtService{
-		secretKey:          cfg.SecretKey,
-		signingMethod:      jwt.SigningMethodHS512,
+		algorithm:          cfg.Algorithm,
 		lifetime:           cfg.Lifetime,
+		keyRotation:        cfg.KeyRotation,
+		keyOverlap:         cfg.KeyOverlap,
+		keyRepository:      keyRepository,
+		keyCipher:          keyCipher,
+		now:                time.Now,
 		issuer:             cfg.Issuer,
 		audience:           cfg.Audience,
 		refreshTokenLength: 32,
+		refreshLifetime:    cfg.RefreshLifetime,
 	}, nil
 }
 
-func (s *JwtService) CreateToken(extraClaims map[string]string) (string, error) {
+func (s *JwtService) CreateToken(subject TokenSubject, extraClaims map[string]string) (string, error) {
 	claims := CustomClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject.UserID,
 			Issuer:    s.issuer,
 			Audience:  jwt.ClaimStrings{s.audience},
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.lifetime)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.NewString(),
 		},
+		SessionID:   subject.SessionID,
+		Roles:       subject.Roles,
 		ExtraClaims: extraClaims,
 	}
 
-	token := jwt.NewWithClaims(s.signingMethod, claims)
+	key, err := s.signingKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
 
-	signedToken, err := token.SignedString([]byte(s.secretKey))
+	signedToken, err := token.SignedString(key.private)
 	if err != nil {
 		return "", err
 	}
@@ -61,29 +130,70 @@ func (s *JwtService) CreateToken(extraClaims map[string]string) (string, error)
 	return signedToken, nil
 }
 
+// ValidateToken verifies the signature and the registered claims of an access token. The errors are
+// errs.Token* sentinels, so that the reason can be reported to the client.
 func (s *JwtService) ValidateToken(token string) (*CustomClaims, error) {
 
-	parsedToken, err := jwt.ParseWithClaims(token, &CustomClaims{}, func(token *jwt.Token) (any, error) {
-
-		if token.Method != s.signingMethod {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-
-		return []byte(s.secretKey), nil
-	})
-
+	parsedToken, err := jwt.ParseWithClaims(token, &CustomClaims{}, s.VerificationKey,
+		jwt.WithValidMethods([]string{AlgorithmRS256, AlgorithmEdDSA}),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithAudience(s.audience),
+		jwt.WithExpirationRequired(),
+	)
 	if err != nil {
-		return nil, err
+		return nil, tokenError(err)
 	}
 
 	claims, ok := parsedToken.Claims.(*CustomClaims)
 	if !ok || !parsedToken.Valid {
-		return nil, fmt.Errorf("invalid token")
+		return nil, errs.TokenInvalidClaims
 	}
 
-	if s.issuer != claims.Issuer || len(claims.Audience) == 0 || s.audience != claims.Audience[0] {
-		return nil, fmt.Errorf("invalid audience or issuer")
+	// revocation needs the id and the issue time, and every token is issued to a user
+	if claims.Subject == "" || claims.ID == "" || claims.IssuedAt == nil {
+		return nil, fmt.Errorf("%w: sub, jti and iat are required", errs.TokenInvalidClaims)
 	}
 
 	return claims, nil
 }
+
+// tokenError maps a jwt validation error to the sentinel of its first failed check.
+func tokenError(err error) error {
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return fmt.Errorf("%w: %w", errs.TokenMalformed, err)
+	case errors.Is(err, jwt.ErrTokenUnverifiable) || errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return fmt.Errorf("%w: %w", errs.TokenInvalidSignature, err)
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return fmt.Errorf("%w: %w", errs.TokenExpired, err)
+	case errors.Is(err, jwt.ErrTokenNotValidYet) || errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return fmt.Errorf("%w: %w", errs.TokenNotValidYet, err)
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return fmt.Errorf("%w: %w", errs.TokenInvalidIssuer, err)
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return fmt.Errorf("%w: %w", errs.TokenInvalidAudience, err)
+	default:
+		return fmt.Errorf("%w: %w", errs.TokenInvalidClaims, err)
+	}
+}
+
+// CreateRefreshToken returns a random opaque token and the record to store for it, which holds only its hash.
+func (s *JwtService) CreateRefreshToken() (string, *models.RefreshToken, error) {
+
+	buf := make([]byte, s.refreshTokenLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, &models.RefreshToken{
+		Hash:      s.HashRefreshToken(token),
+		ExpiresAt: time.Now().Add(s.refreshLifetime),
+	}, nil
+}
+
+// HashRefreshToken hashes a token to look it up. A refresh token is random, so a plain hash is enough.
+func (s *JwtService) HashRefreshToken(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
@@ -1,104 +1,360 @@
 package services
 
 import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"log"
+	"sync"
+	errs "test-task/wallet/internal/domain/errors"
+	"test-task/wallet/internal/domain/models"
 	"testing"
 	"time"
 )
 
+type keyRepositoryMock struct {
+	mu   sync.Mutex
+	keys []models.SigningKey
+}
+
+func (r *keyRepositoryMock) AddSigningKey(ctx context.Context, key *models.SigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys = append(r.keys, *key)
+	return nil
+}
+
+func (r *keyRepositoryMock) GetSigningKeys(ctx context.Context, createdAfter time.Time) ([]models.SigningKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var res []models.SigningKey
+	for _, key := range r.keys {
+		if key.CreatedAt.After(createdAfter) {
+			res = append(res, key)
+		}
+	}
+	return res, nil
+}
+
+var testSubject = TokenSubject{UserID: "12345", SessionID: "session", Roles: []string{"user"}}
+
+var testJwtConfig = JWTConfig{
+	Algorithm:        AlgorithmEdDSA,
+	Lifetime:         time.Minute,
+	KeyRotation:      24 * time.Hour,
+	KeyOverlap:       10 * time.Minute,
+	Issuer:           "MyIssuer",
+	Audience:         "MyAudience",
+	KeyEncryptionKey: bytes.Repeat([]byte{1}, KeyEncryptionKeySize),
+}
+
 func Test_CreateToken_ShouldCreateValidToken(t *testing.T) {
 
-	claims := map[string]string{"UserId": "12345", "Ip": "127.0.0.1"}
-	cfg := JWTConfig{
-		SecretKey: "123",
-		Lifetime:  time.Minute,
-		Issuer:    "MyIssuer",
-		Audience:  "MyAudience",
+	for _, algorithm := range []string{AlgorithmRS256, AlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+
+			claims := map[string]string{"UserId": "12345", "Ip": "127.0.0.1"}
+			cfg := testJwtConfig
+			cfg.Algorithm = algorithm
+
+			jwtService, err := createJwtService(cfg, &keyRepositoryMock{})
+			assert.NoError(t, err)
+
+			token, err := jwtService.CreateToken(testSubject, claims)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, token)
+
+			claimsFromToken, err := jwtService.ValidateToken(token)
+			assert.NoError(t, err)
+
+			assert.Equal(t, claims, claimsFromToken.ExtraClaims)
+			assert.Equal(t, testSubject.UserID, claimsFromToken.Subject)
+			assert.Equal(t, testSubject.SessionID, claimsFromToken.SessionID)
+			assert.Equal(t, testSubject.Roles, claimsFromToken.Roles)
+		})
 	}
+}
+
+func Test_CreateToken_ShouldSetUniqueID(t *testing.T) {
+
+	jwtService := createJwtServiceWithIssuerAndAudience("MyIssuer", "MyAudience", &keyRepositoryMock{})
 
-	jwtService, err := NewJwtService(cfg)
+	first, err := jwtService.CreateToken(testSubject, nil)
+	assert.NoError(t, err)
+	second, err := jwtService.CreateToken(testSubject, nil)
 	assert.NoError(t, err)
 
-	token, err := jwtService.CreateToken(claims)
+	firstClaims, err := jwtService.ValidateToken(first)
 	assert.NoError(t, err)
-	assert.NotEmpty(t, token)
+	secondClaims, err := jwtService.ValidateToken(second)
+	assert.NoError(t, err)
+
+	assert.NotEmpty(t, firstClaims.ID)
+	assert.NotEqual(t, firstClaims.ID, secondClaims.ID)
+}
+
+func Test_CreateToken_ShouldKeepIssuedAtWithMilliseconds(t *testing.T) {
+
+	jwtService := createJwtServiceWithIssuerAndAudience("MyIssuer", "MyAudience", &keyRepositoryMock{})
+
+	before := time.Now()
+	token, err := jwtService.CreateToken(testSubject, nil)
+	assert.NoError(t, err)
+	after := time.Now()
 
-	claimsFromToken, err := jwtService.ValidateToken(token)
+	claims, err := jwtService.ValidateToken(token)
 	assert.NoError(t, err)
 
-	assert.Equal(t, claims, claimsFromToken.ExtraClaims)
+	// the parsed time may lose a millisecond to float rounding, but not the fraction of the second
+	assert.False(t, claims.IssuedAt.Before(before.Add(-2*time.Millisecond)))
+	assert.False(t, claims.IssuedAt.After(after))
 }
 
 func Test_ValidateToken_WhenInvalidIssuer_ShouldReturnError(t *testing.T) {
 
-	cfg := JWTConfig{
-		SecretKey: "123",
-		Lifetime:  time.Minute,
-		Issuer:    "MyIssuer",
-		Audience:  "MyAudience",
-	}
-	jwtService1, err := NewJwtService(cfg)
+	keys := &keyRepositoryMock{}
+	jwtService1, err := createJwtService(testJwtConfig, keys)
 	assert.NoError(t, err)
 
-	jwtService2 := createJwtServiceWithIssuerAndAudience("AnotherIssuer", cfg.Audience)
+	jwtService2 := createJwtServiceWithIssuerAndAudience("AnotherIssuer", testJwtConfig.Audience, keys)
 
-	token, err := jwtService2.CreateToken(map[string]string{"UserId": "12345", "Ip": "127.0.0.1"})
+	token, err := jwtService2.CreateToken(testSubject, map[string]string{"Ip": "127.0.0.1"})
 	assert.NoError(t, err)
 
 	_, err = jwtService1.ValidateToken(token)
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, errs.TokenInvalidIssuer)
 }
 
 func Test_ValidateToken_WhenInvalidAudience_ShouldReturnError(t *testing.T) {
 
-	cfg := JWTConfig{
-		SecretKey: "123",
-		Lifetime:  time.Minute,
-		Issuer:    "MyIssuer",
-		Audience:  "MyAudience",
-	}
-	jwtService1, err := NewJwtService(cfg)
+	keys := &keyRepositoryMock{}
+	jwtService1, err := createJwtService(testJwtConfig, keys)
 	assert.NoError(t, err)
 
-	jwtService2 := createJwtServiceWithIssuerAndAudience(cfg.Issuer, "AnotherAudience")
+	jwtService2 := createJwtServiceWithIssuerAndAudience(testJwtConfig.Issuer, "AnotherAudience", keys)
 
-	token, err := jwtService2.CreateToken(map[string]string{"UserId": "12345", "Ip": "127.0.0.1"})
+	token, err := jwtService2.CreateToken(testSubject, map[string]string{"Ip": "127.0.0.1"})
 	assert.NoError(t, err)
 
 	_, err = jwtService1.ValidateToken(token)
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, errs.TokenInvalidAudience)
 }
 
 func Test_ValidateToken_WhenExpired_ShouldReturnError(t *testing.T) {
 
-	cfg := JWTConfig{
-		SecretKey: "123",
-		Lifetime:  time.Second,
-		Issuer:    "MyIssuer",
-		Audience:  "MyAudience",
-	}
-	jwtService, err := NewJwtService(cfg)
+	cfg := testJwtConfig
+	cfg.Lifetime = time.Second
+
+	jwtService, err := createJwtService(cfg, &keyRepositoryMock{})
 	assert.NoError(t, err)
 
-	token, err := jwtService.CreateToken(map[string]string{"UserId": "12345", "Ip": "127.0.0.1"})
+	token, err := jwtService.CreateToken(testSubject, map[string]string{"Ip": "127.0.0.1"})
 	assert.NoError(t, err)
 
 	time.Sleep(cfg.Lifetime + time.Second)
 
 	_, err = jwtService.ValidateToken(token)
+	assert.ErrorIs(t, err, errs.TokenExpired)
+}
+
+func Test_ValidateToken_WhenSignedByUnknownKey_ShouldReturnError(t *testing.T) {
+
+	jwtService1 := createJwtServiceWithIssuerAndAudience(testJwtConfig.Issuer, testJwtConfig.Audience, &keyRepositoryMock{})
+	jwtService2 := createJwtServiceWithIssuerAndAudience(testJwtConfig.Issuer, testJwtConfig.Audience, &keyRepositoryMock{})
+
+	token, err := jwtService2.CreateToken(testSubject, nil)
+	assert.NoError(t, err)
+
+	_, err = jwtService1.ValidateToken(token)
+	assert.ErrorIs(t, err, errs.TokenInvalidSignature)
+}
+
+func Test_ValidateToken_WhenAlgorithmDoesNotMatchKey_ShouldReturnError(t *testing.T) {
+
+	jwtService := createJwtServiceWithIssuerAndAudience(testJwtConfig.Issuer, testJwtConfig.Audience, &keyRepositoryMock{})
+	kid := jwtService.PublicKeys()[0].ID
+
+	// a token signed with a shared secret that names the public key must not pass
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    testJwtConfig.Issuer,
+		Audience:  jwt.ClaimStrings{testJwtConfig.Audience},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString([]byte(kid))
+	require.NoError(t, err)
+
+	_, err = jwtService.ValidateToken(signed)
+	assert.ErrorIs(t, err, errs.TokenInvalidSignature)
+}
+
+func Test_ValidateToken_WhenNotValidYet_ShouldReturnError(t *testing.T) {
+
+	jwtService := createJwtServiceWithIssuerAndAudience(testJwtConfig.Issuer, testJwtConfig.Audience, &keyRepositoryMock{})
+	key, err := jwtService.signingKey()
+	require.NoError(t, err)
+
+	token := jwt.NewWithClaims(key.method, CustomClaims{RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   testSubject.UserID,
+		Issuer:    testJwtConfig.Issuer,
+		Audience:  jwt.ClaimStrings{testJwtConfig.Audience},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(2 * time.Minute)),
+		NotBefore: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ID:        "id",
+	}})
+	token.Header["kid"] = key.id
+	signed, err := token.SignedString(key.private)
+	require.NoError(t, err)
+
+	_, err = jwtService.ValidateToken(signed)
+	assert.ErrorIs(t, err, errs.TokenNotValidYet)
+}
+
+func Test_ValidateToken_WhenNoSubject_ShouldReturnError(t *testing.T) {
+
+	jwtService := createJwtServiceWithIssuerAndAudience(testJwtConfig.Issuer, testJwtConfig.Audience, &keyRepositoryMock{})
+
+	token, err := jwtService.CreateToken(TokenSubject{}, nil)
+	require.NoError(t, err)
+
+	_, err = jwtService.ValidateToken(token)
+	assert.ErrorIs(t, err, errs.TokenInvalidClaims)
+}
+
+func Test_ValidateToken_WhenMalformed_ShouldReturnError(t *testing.T) {
+
+	jwtService := createJwtServiceWithIssuerAndAudience(testJwtConfig.Issuer, testJwtConfig.Audience, &keyRepositoryMock{})
+
+	_, err := jwtService.ValidateToken("not.a.token")
+	assert.ErrorIs(t, err, errs.TokenMalformed)
+}
+
+func Test_RotateKeys_ShouldPublishNewKeyBeforeSigning(t *testing.T) {
+
+	now := time.Now()
+	jwtService, err := NewJwtService(testJwtConfig, &keyRepositoryMock{})
+	require.NoError(t, err)
+	jwtService.now = func() time.Time { return now }
+
+	require.NoError(t, jwtService.RotateKeys(context.Background()))
+	first := signingKeyID(t, jwtService)
+	oldToken, err := jwtService.CreateToken(testSubject, nil)
+	require.NoError(t, err)
+
+	// the rotation is due an overlap before the first key has served its rotation period
+	now = now.Add(testJwtConfig.KeyRotation - testJwtConfig.KeyOverlap)
+	require.NoError(t, jwtService.RotateKeys(context.Background()))
+	assert.Len(t, jwtService.PublicKeys(), 2)
+	assert.Equal(t, first, signingKeyID(t, jwtService))
+
+	now = now.Add(testJwtConfig.KeyOverlap)
+	require.NoError(t, jwtService.RotateKeys(context.Background()))
+	second := signingKeyID(t, jwtService)
+	assert.NotEqual(t, first, second)
+
+	// tokens of the first key are still accepted during the overlap
+	_, err = jwtService.ValidateToken(oldToken)
+	assert.NoError(t, err)
+
+	now = now.Add(2 * testJwtConfig.KeyOverlap)
+	require.NoError(t, jwtService.RotateKeys(context.Background()))
+	assert.Len(t, jwtService.PublicKeys(), 1)
+	assert.Equal(t, second, signingKeyID(t, jwtService))
+}
+
+func Test_RotateKeys_ShouldShareKeysBetweenInstances(t *testing.T) {
+
+	keys := &keyRepositoryMock{}
+	jwtService1 := createJwtServiceWithIssuerAndAudience(testJwtConfig.Issuer, testJwtConfig.Audience, keys)
+	jwtService2 := createJwtServiceWithIssuerAndAudience(testJwtConfig.Issuer, testJwtConfig.Audience, keys)
+
+	assert.Len(t, keys.keys, 1)
+	assert.Equal(t, signingKeyID(t, jwtService1), signingKeyID(t, jwtService2))
+
+	token, err := jwtService1.CreateToken(testSubject, nil)
+	assert.NoError(t, err)
+	_, err = jwtService2.ValidateToken(token)
+	assert.NoError(t, err)
+}
+
+func Test_RotateKeys_ShouldStoreKeysEncrypted(t *testing.T) {
+
+	keys := &keyRepositoryMock{}
+	_, err := createJwtService(testJwtConfig, keys)
+	require.NoError(t, err)
+
+	require.Len(t, keys.keys, 1)
+	_, err = x509.ParsePKCS8PrivateKey(keys.keys[0].PrivateKey)
+	assert.Error(t, err, "the private key must not be stored in the clear")
+
+	// the keys can't be used without the key encryption key
+	cfg := testJwtConfig
+	cfg.KeyEncryptionKey = bytes.Repeat([]byte{2}, KeyEncryptionKeySize)
+	_, err = createJwtService(cfg, keys)
+	assert.Error(t, err)
+
+	// nor be moved to another row
+	keys.keys[0].ID = "another"
+	_, err = createJwtService(testJwtConfig, keys)
 	assert.Error(t, err)
 }
 
-func createJwtServiceWithIssuerAndAudience(issuer string, audience string) *JwtService {
+func Test_NewJwtService_WhenOverlapShorterThanLifetime_ShouldReturnError(t *testing.T) {
+
+	cfg := testJwtConfig
+	cfg.KeyOverlap = cfg.Lifetime / 2
+
+	_, err := NewJwtService(cfg, &keyRepositoryMock{})
+	assert.Error(t, err)
+}
+
+func Test_CreateRefreshToken_ShouldStoreOnlyHash(t *testing.T) {
+
+	cfg := testJwtConfig
+	cfg.RefreshLifetime = time.Hour
+
+	jwtService, err := NewJwtService(cfg, &keyRepositoryMock{})
+	assert.NoError(t, err)
+
+	token, stored, err := jwtService.CreateRefreshToken()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+
+	assert.NotEqual(t, []byte(token), stored.Hash)
+	assert.Equal(t, jwtService.HashRefreshToken(token), stored.Hash)
+	assert.WithinDuration(t, time.Now().Add(cfg.RefreshLifetime), stored.ExpiresAt, time.Second)
+
+	another, _, err := jwtService.CreateRefreshToken()
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, another)
+}
+
+func signingKeyID(t *testing.T, jwtService *JwtService) string {
+	key, err := jwtService.signingKey()
+	require.NoError(t, err)
+	return key.id
+}
 
-	cfg := JWTConfig{
-		SecretKey: "123",
-		Lifetime:  time.Minute,
-		Issuer:    issuer,
-		Audience:  audience,
+func createJwtService(cfg JWTConfig, keys SigningKeyRepository) (*JwtService, error) {
+
+	jwtService, err := NewJwtService(cfg, keys)
+	if err != nil {
+		return nil, err
 	}
-	jwt, err := NewJwtService(cfg)
+	return jwtService, jwtService.RotateKeys(context.Background())
+}
+
+func createJwtServiceWithIssuerAndAudience(issuer string, audience string, keys SigningKeyRepository) *JwtService {
+
+	cfg := testJwtConfig
+	cfg.Issuer = issuer
+	cfg.Audience = audience
+
+	jwt, err := createJwtService(cfg, keys)
 
 	if err != nil {
 		log.Fatalf("failed to create jwt service: %v", err)
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"log/slog"
+	"slices"
+	"strings"
+	"test-task/wallet/internal/domain/models"
+	"time"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// keyRefreshInterval is how often keys created by other instances are picked up.
+// The key overlap must be longer, so that every instance knows a new key before it starts signing.
+const keyRefreshInterval = time.Minute
+
+const rsaKeyBits = 2048
+
+// KeyEncryptionKeySize is the size of the AES-256 key the private signing keys are encrypted with.
+const KeyEncryptionKeySize = 32
+
+type SigningKeyRepository interface {
+	AddSigningKey(ctx context.Context, key *models.SigningKey) error
+	GetSigningKeys(ctx context.Context, createdAfter time.Time) ([]models.SigningKey, error)
+}
+
+type signingKey struct {
+	id        string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	createdAt time.Time
+}
+
+// newKeyCipher returns the cipher the private signing keys are encrypted with before they are stored,
+// so that reading the database is not enough to sign tokens.
+func newKeyCipher(keyEncryptionKey []byte) (cipher.AEAD, error) {
+
+	if len(keyEncryptionKey) != KeyEncryptionKeySize {
+		return nil, fmt.Errorf("key encryption key must be %d bytes", KeyEncryptionKeySize)
+	}
+
+	block, err := aes.NewCipher(keyEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// keyAssociatedData binds an encrypted private key to its id and algorithm, so that it can't be moved
+// to another row of the table.
+func keyAssociatedData(id, algorithm string) []byte {
+	return []byte(id + "/" + algorithm)
+}
+
+func generateSigningKey(keyCipher cipher.AEAD, algorithm string, now time.Time) (*models.SigningKey, error) {
+
+	var private any
+	var err error
+
+	switch algorithm {
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signing key: %w", err)
+	}
+
+	nonce := make([]byte, keyCipher.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	id := uuid.NewString()
+	encrypted := keyCipher.Seal(nonce, nonce, der, keyAssociatedData(id, algorithm))
+	return &models.SigningKey{ID: id, Algorithm: algorithm, PrivateKey: encrypted, CreatedAt: now}, nil
+}
+
+func parseSigningKey(keyCipher cipher.AEAD, key models.SigningKey) (*signingKey, error) {
+
+	if len(key.PrivateKey) < keyCipher.NonceSize() {
+		return nil, fmt.Errorf("signing key %s is not encrypted", key.ID)
+	}
+	nonce, encrypted := key.PrivateKey[:keyCipher.NonceSize()], key.PrivateKey[keyCipher.NonceSize():]
+	der, err := keyCipher.Open(nil, nonce, encrypted, keyAssociatedData(key.ID, key.Algorithm))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt signing key %s, is the key encryption key right? %w", key.ID, err)
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %w", key.ID, err)
+	}
+
+	var ok bool
+	switch key.Algorithm {
+	case AlgorithmRS256:
+		_, ok = private.(*rsa.PrivateKey)
+	case AlgorithmEdDSA:
+		_, ok = private.(ed25519.PrivateKey)
+	default:
+		return nil, fmt.Errorf("signing key %s has unsupported algorithm %q", key.ID, key.Algorithm)
+	}
+	if !ok {
+		return nil, fmt.Errorf("signing key %s does not match algorithm %s", key.ID, key.Algorithm)
+	}
+
+	return &signingKey{
+		id:        key.ID,
+		method:    jwt.GetSigningMethod(key.Algorithm),
+		private:   private.(crypto.Signer),
+		createdAt: key.CreatedAt,
+	}, nil
+}
+
+// RotateKeys loads the keys and creates a new one when the newest key is due to be replaced or uses another
+// algorithm. A key is published for the overlap window before it starts signing and after it stops, so that
+// verifiers know it as long as its tokens live. Instances sharing the repository agree on the signing key.
+func (s *JwtService) RotateKeys(ctx context.Context) error {
+
+	now := s.now()
+
+	// a key is relevant for one rotation period and two overlap windows at most
+	stored, err := s.keyRepository.GetSigningKeys(ctx, now.Add(-s.keyRotation-2*s.keyOverlap))
+	if err != nil {
+		return fmt.Errorf("failed to get signing keys: %w", err)
+	}
+
+	slices.SortFunc(stored, func(a, b models.SigningKey) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	if len(stored) == 0 || s.isRotationDue(stored[len(stored)-1], now) {
+		key, err := generateSigningKey(s.keyCipher, s.algorithm, now)
+		if err != nil {
+			return err
+		}
+		if err = s.keyRepository.AddSigningKey(ctx, key); err != nil {
+			return fmt.Errorf("failed to store signing key: %w", err)
+		}
+		slog.Info("created signing key", "kid", key.ID, "algorithm", key.Algorithm)
+		stored = append(stored, *key)
+	}
+
+	keys := make([]*signingKey, 0, len(stored))
+	for _, key := range stored {
+		parsed, err := parseSigningKey(s.keyCipher, key)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, parsed)
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *JwtService) isRotationDue(newest models.SigningKey, now time.Time) bool {
+	return newest.Algorithm != s.algorithm || !now.Before(newest.CreatedAt.Add(s.keyRotation-s.keyOverlap))
+}
+
+// WatchKeys rotates the keys and picks up the keys of other instances until ctx is done.
+func (s *JwtService) WatchKeys(ctx context.Context) {
+
+	ticker := time.NewTicker(keyRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.RotateKeys(ctx); err != nil {
+				slog.Error("failed to rotate signing keys", "error", err)
+			}
+		}
+	}
+}
+
+// signingKey returns the newest key that has been published for the overlap window. If there is none,
+// the oldest key is used: it is the only one right after the first start or a long downtime.
+func (s *JwtService) signingKey() (*signingKey, error) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.keys) == 0 {
+		return nil, fmt.Errorf("no signing keys loaded")
+	}
+
+	now := s.now()
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if !now.Before(s.keys[i].createdAt.Add(s.keyOverlap)) {
+			return s.keys[i], nil
+		}
+	}
+	return s.keys[0], nil
+}
+
+// VerificationKey is a jwt.Keyfunc: it returns the public key named by the kid header,
+// provided that the token is signed with the algorithm of that key.
+func (s *JwtService) VerificationKey(token *jwt.Token) (any, error) {
+
+	id, ok := token.Header["kid"].(string)
+	if !ok {
+		return nil, fmt.Errorf("token has no key id")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.keys {
+		if key.id != id {
+			continue
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.private.Public(), nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", id)
+}
+
+// PublicKeys returns every key tokens may currently be signed with.
+func (s *JwtService) PublicKeys() []models.PublicKey {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]models.PublicKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, models.PublicKey{ID: key.id, Algorithm: key.method.Alg(), Key: key.private.Public()})
+	}
+	return keys
+}
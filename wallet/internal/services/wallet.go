@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"log/slog"
+	"strconv"
 	errs "test-task/wallet/internal/domain/errors"
 	"test-task/wallet/internal/domain/models"
 	"test-task/wallet/internal/tracing"
@@ -12,32 +15,78 @@ import (
 )
 
 type Redis interface {
-	StoreRate(ctx context.Context, from models.Currency, to models.Currency, value float64, expiration time.Duration) error
-	StoreRates(ctx context.Context, rates map[models.Currency]float64, base models.Currency, expiration time.Duration) error
-	GetRate(ctx context.Context, from models.Currency, to models.Currency) (float64, error)
-	GetRates(ctx context.Context, base models.Currency) (map[models.Currency]float64, error)
+	StoreRate(ctx context.Context, from models.Currency, to models.Currency, value decimal.Decimal, expiration time.Duration) error
+	StoreRates(ctx context.Context, rates *models.ExchangeRates, expiration time.Duration) error
+	GetRate(ctx context.Context, from models.Currency, to models.Currency) (decimal.Decimal, error)
+	GetRates(ctx context.Context) (*models.ExchangeRates, error)
+	ReplaceRates(ctx context.Context, rates *models.ExchangeRates, expiration time.Duration) error
+	StoreQuote(ctx context.Context, quote *models.Quote, expiration time.Duration) error
+	TakeQuote(ctx context.Context, userID, id string) (*models.Quote, error)
 }
 
 type ExchangerClient interface {
-	GetExchangeRates(ctx context.Context) (map[models.Currency]float64, error)
-	GetExchangeRateForOne(ctx context.Context, from models.Currency, to models.Currency) (float64, error)
+	GetExchangeRates(ctx context.Context) (*models.ExchangeRates, error)
+	GetExchangeRateForOne(ctx context.Context, from models.Currency, to models.Currency) (decimal.Decimal, error)
+	StreamExchangeRates(ctx context.Context, onUpdate func(*models.ExchangeRates)) error
 }
 
 type AccountsRepository interface {
-	GetBalance(ctx context.Context, userID string) (map[models.Currency]float64, error)
+	GetBalance(ctx context.Context, userID string) (map[models.Currency]decimal.Decimal, error)
 	ChangeAccountAmountWithBalance(ctx context.Context, userID string, currency models.Currency,
-		delta float64) (map[models.Currency]float64, error)
+		delta decimal.Decimal, transactionType models.TransactionType,
+		limit models.Limit) (map[models.Currency]decimal.Decimal, error)
 	ExchangeAccountAmountWithBalance(ctx context.Context, userID string, from models.Currency,
-		fromAmount float64, to models.Currency, toAmount float64) (map[models.Currency]float64, error)
+		fromAmount decimal.Decimal, to models.Currency, toAmount decimal.Decimal,
+		rate decimal.Decimal, fee decimal.Decimal, limit models.Limit) (map[models.Currency]decimal.Decimal, error)
+	TransferAccountAmountWithBalance(ctx context.Context, fromUserID string, toUserID string,
+		from models.Currency, fromAmount decimal.Decimal, to models.Currency, toAmount decimal.Decimal,
+		rate decimal.Decimal, fee decimal.Decimal, withdrawLimit models.Limit,
+		exchangeLimit models.Limit) (map[models.Currency]decimal.Decimal, error)
+	GetTransactions(ctx context.Context, userID string, filter models.TransactionFilter) ([]models.Transaction, error)
+	GetUserByNameOrEmail(ctx context.Context, login string) (*models.User, error)
+	GetUserStatus(ctx context.Context, userID string) (models.UserStatus, error)
 }
 
 type BalanceInfo struct {
-	Accounts map[models.Currency]float64
+	Accounts map[models.Currency]decimal.Decimal
 }
 
 type ExchangeInfo struct {
-	Accounts        map[models.Currency]float64
-	ExchangedAmount float64
+	Accounts        map[models.Currency]decimal.Decimal
+	ExchangedAmount decimal.Decimal
+	Fee             decimal.Decimal
+}
+
+type TransferInfo struct {
+	Accounts       map[models.Currency]decimal.Decimal
+	CreditedAmount decimal.Decimal
+	Rate           decimal.Decimal
+	Fee            decimal.Decimal
+}
+
+// TransactionsPage is one page of a user's transaction history, newest first.
+// NextCursor is zero when there are no more transactions.
+type TransactionsPage struct {
+	Transactions []models.Transaction
+	NextCursor   int64
+}
+
+const (
+	defaultTransactionsLimit = 20
+	maxTransactionsLimit     = 100
+)
+
+// ratesStreamRetryDelay is the pause before subscribing to the rate stream again after it broke.
+const ratesStreamRetryDelay = 5 * time.Second
+
+// quoteRetention is how long a quote is kept after it expires, so that using it reports "expired"
+// rather than "not found".
+const quoteRetention = time.Hour
+
+type WalletConfig struct {
+	QuoteLifetime time.Duration
+	Fees          models.FeeSchedule
+	Limits        models.Limits
 }
 
 type WalletService struct {
@@ -45,23 +94,30 @@ type WalletService struct {
 	exchangerClient ExchangerClient
 	redis           Redis
 	ratesExpiration time.Duration
+	quoteLifetime   time.Duration
+	fees            models.FeeSchedule
+	limits          models.Limits
 }
 
-func NewWalletService(accounts AccountsRepository, exchangerClient ExchangerClient, redis Redis) *WalletService {
+func NewWalletService(accounts AccountsRepository, exchangerClient ExchangerClient, redis Redis,
+	cfg WalletConfig) *WalletService {
 	return &WalletService{
 		accounts:        accounts,
 		exchangerClient: exchangerClient,
 		redis:           redis,
 		ratesExpiration: 5 * time.Minute,
+		quoteLifetime:   cfg.QuoteLifetime,
+		fees:            cfg.Fees,
+		limits:          cfg.Limits,
 	}
 }
 
-func (w *WalletService) GetExchangeRates(ctx context.Context) (map[models.Currency]float64, error) {
+func (w *WalletService) GetExchangeRates(ctx context.Context) (*models.ExchangeRates, error) {
 
 	ctx, span := tracing.GetTracer().Start(ctx, "GetExchangeRates")
 	defer span.End()
 
-	rates, err := w.redis.GetRates(ctx, models.USD)
+	rates, err := w.redis.GetRates(ctx)
 	if err != nil {
 		if errors.Is(err, errs.KeyNotExists) {
 			slog.Debug("key does not exist")
@@ -77,7 +133,7 @@ func (w *WalletService) GetExchangeRates(ctx context.Context) (map[models.Curren
 		return nil, err
 	}
 
-	err = w.redis.StoreRates(ctx, rates, models.USD, w.ratesExpiration)
+	err = w.redis.StoreRates(ctx, rates, w.ratesExpiration)
 	if err != nil {
 		slog.Error("failed to store rates in cache:", "error", err)
 	}
@@ -85,70 +141,260 @@ func (w *WalletService) GetExchangeRates(ctx context.Context) (map[models.Curren
 	return rates, nil
 }
 
-func (w *WalletService) Exchange(ctx context.Context, userID string, from models.Currency, to models.Currency, amount float64) (*ExchangeInfo, error) {
+// WatchExchangeRates keeps the cached rates up to date with the rate stream of the exchanger until ctx is done.
+// While the stream is down, the cache falls back to expiring after ratesExpiration.
+func (w *WalletService) WatchExchangeRates(ctx context.Context) {
+	for {
+		err := w.exchangerClient.StreamExchangeRates(ctx, func(rates *models.ExchangeRates) {
+			if err := w.redis.ReplaceRates(ctx, rates, w.ratesExpiration); err != nil {
+				slog.Error("failed to refresh rates in cache", "error", err)
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Error("exchange rates stream broke", "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(ratesStreamRetryDelay):
+		}
+	}
+}
+
+func (w *WalletService) Exchange(ctx context.Context, userID string, from models.Currency, to models.Currency, amount decimal.Decimal) (*ExchangeInfo, error) {
 
 	ctx, span := tracing.GetTracer().Start(ctx, "Exchange")
 	defer span.End()
 
-	if amount <= 0 {
-		return nil, errs.InvalidAmount
+	price, err := w.priceExchange(ctx, from, to, amount)
+	if err != nil {
+		return nil, err
 	}
 
+	limit := w.limits.For(models.ExchangeTransaction, from)
+	if !limit.AllowsOperation(amount) {
+		return nil, errs.LimitExceeded
+	}
+
+	balance, err := w.accounts.ExchangeAccountAmountWithBalance(ctx, userID, from, amount, to,
+		price.exchangedAmount, price.rate, price.fee, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange: %w", err)
+	}
+
+	return &ExchangeInfo{Accounts: balance, ExchangedAmount: price.exchangedAmount, Fee: price.fee}, nil
+}
+
+type exchangePrice struct {
+	rate            decimal.Decimal
+	fee             decimal.Decimal
+	exchangedAmount decimal.Decimal
+}
+
+// priceExchange validates an exchange and calculates its fee and the amount credited after the fee is taken.
+func (w *WalletService) priceExchange(ctx context.Context, from models.Currency, to models.Currency,
+	amount decimal.Decimal) (*exchangePrice, error) {
+
 	if !from.IsValid() || !to.IsValid() || from == to {
 		return nil, errs.InvalidCurrency
 	}
 
+	if !from.IsValidAmount(amount) {
+		return nil, errs.InvalidAmount
+	}
+
 	rate, err := w.getExchangeRate(ctx, from, to)
 	if err != nil {
 		return nil, err
 	}
 
-	balance, err := w.accounts.ExchangeAccountAmountWithBalance(ctx, userID, from, amount, to, amount*rate)
+	fee := w.fees.Fee(from, to, amount)
+	exchangedAmount := convert(amount.Sub(fee), rate, to)
+	if !exchangedAmount.IsPositive() {
+		return nil, errs.InvalidAmount
+	}
+
+	return &exchangePrice{rate: rate, fee: fee, exchangedAmount: exchangedAmount}, nil
+}
+
+// CreateQuote fixes the current rate for exchanging amount from one currency to another.
+// The quote can be executed once with ExchangeWithQuote until it expires.
+func (w *WalletService) CreateQuote(ctx context.Context, userID string, from models.Currency, to models.Currency,
+	amount decimal.Decimal) (*models.Quote, error) {
+
+	ctx, span := tracing.GetTracer().Start(ctx, "CreateQuote")
+	defer span.End()
+
+	price, err := w.priceExchange(ctx, from, to, amount)
 	if err != nil {
+		return nil, err
+	}
+
+	if err = w.checkActive(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	quote := &models.Quote{
+		ID:              uuid.NewString(),
+		UserID:          userID,
+		From:            from,
+		To:              to,
+		Amount:          amount,
+		ExchangedAmount: price.exchangedAmount,
+		Fee:             price.fee,
+		Rate:            price.rate,
+		ExpiresAt:       time.Now().Add(w.quoteLifetime),
+	}
+
+	err = w.redis.StoreQuote(ctx, quote, w.quoteLifetime+quoteRetention)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store quote: %w", err)
+	}
+	return quote, nil
+}
+
+// ExchangeWithQuote executes an exchange at exactly the rate and amounts of a previously created quote.
+func (w *WalletService) ExchangeWithQuote(ctx context.Context, userID string, quoteID string) (*ExchangeInfo, error) {
+
+	ctx, span := tracing.GetTracer().Start(ctx, "ExchangeWithQuote")
+	defer span.End()
+
+	quote, err := w.redis.TakeQuote(ctx, userID, quoteID)
+	if err != nil {
+		if errors.Is(err, errs.KeyNotExists) {
+			return nil, errs.QuoteNotExists
+		}
+		return nil, fmt.Errorf("failed to get quote: %w", err)
+	}
+
+	if quote.IsExpired(time.Now()) {
+		return nil, errs.QuoteExpired
+	}
+
+	limit := w.limits.For(models.ExchangeTransaction, quote.From)
+	if !limit.AllowsOperation(quote.Amount) {
+		return nil, errs.LimitExceeded
+	}
+
+	balance, err := w.accounts.ExchangeAccountAmountWithBalance(ctx, userID, quote.From, quote.Amount,
+		quote.To, quote.ExchangedAmount, quote.Rate, quote.Fee, limit)
+	if err != nil {
+		// give the quote back, so that the user can retry it, e.g. after a deposit or once unfrozen
+		if storeErr := w.redis.StoreQuote(ctx, quote, time.Until(quote.ExpiresAt)+quoteRetention); storeErr != nil {
+			slog.Error("failed to restore quote", "error", storeErr)
+		}
 		return nil, fmt.Errorf("failed to exchange: %w", err)
 	}
 
-	return &ExchangeInfo{Accounts: balance, ExchangedAmount: amount * rate}, nil
+	return &ExchangeInfo{Accounts: balance, ExchangedAmount: quote.ExchangedAmount, Fee: quote.Fee}, nil
 }
 
-func (w *WalletService) Withdraw(ctx context.Context, userID string, currency models.Currency, amount float64) (*BalanceInfo, error) {
+func (w *WalletService) Withdraw(ctx context.Context, userID string, currency models.Currency, amount decimal.Decimal) (*BalanceInfo, error) {
 
 	ctx, span := tracing.GetTracer().Start(ctx, "Withdraw")
 	defer span.End()
 
-	if amount <= 0 {
+	if !currency.IsValid() {
+		return nil, errs.InvalidCurrency
+	}
+
+	if !currency.IsValidAmount(amount) {
 		return nil, errs.InvalidAmount
 	}
 
-	if !currency.IsValid() {
-		return nil, errs.InvalidCurrency
+	limit := w.limits.For(models.WithdrawTransaction, currency)
+	if !limit.AllowsOperation(amount) {
+		return nil, errs.LimitExceeded
 	}
 
-	balance, err := w.accounts.ChangeAccountAmountWithBalance(ctx, userID, currency, -amount)
+	balance, err := w.accounts.ChangeAccountAmountWithBalance(ctx, userID, currency, amount.Neg(),
+		models.WithdrawTransaction, limit)
 	if err != nil {
 		return nil, err
 	}
 	return &BalanceInfo{Accounts: balance}, nil
 }
 
-func (w *WalletService) Deposit(ctx context.Context, userID string, currency models.Currency, amount float64) (*BalanceInfo, error) {
+func (w *WalletService) Deposit(ctx context.Context, userID string, currency models.Currency, amount decimal.Decimal) (*BalanceInfo, error) {
 
 	ctx, span := tracing.GetTracer().Start(ctx, "Deposit")
 	defer span.End()
 
-	if amount <= 0 {
+	if !currency.IsValid() {
+		return nil, errs.InvalidCurrency
+	}
+
+	if !currency.IsValidAmount(amount) {
 		return nil, errs.InvalidAmount
 	}
 
-	if !currency.IsValid() {
+	balance, err := w.accounts.ChangeAccountAmountWithBalance(ctx, userID, currency, amount,
+		models.DepositTransaction, models.Limit{})
+	if err != nil {
+		return nil, err
+	}
+	return &BalanceInfo{Accounts: balance}, nil
+}
+
+// Transfer moves amount in the from currency from the user to the recipient, identified by username or email.
+// When to differs from from, the recipient is credited in to at the same rate and fee Exchange would use.
+func (w *WalletService) Transfer(ctx context.Context, userID string, recipient string, from models.Currency,
+	to models.Currency, amount decimal.Decimal) (*TransferInfo, error) {
+
+	ctx, span := tracing.GetTracer().Start(ctx, "Transfer")
+	defer span.End()
+
+	if !from.IsValid() || !to.IsValid() {
 		return nil, errs.InvalidCurrency
 	}
 
-	balance, err := w.accounts.ChangeAccountAmountWithBalance(ctx, userID, currency, amount)
+	if !from.IsValidAmount(amount) {
+		return nil, errs.InvalidAmount
+	}
+
+	rate := decimal.NewFromInt(1)
+	fee := decimal.Zero
+	creditedAmount := amount
+	if from != to {
+		// priced like an exchange, so that sending money to a second account is no way around the fees
+		price, err := w.priceExchange(ctx, from, to, amount)
+		if err != nil {
+			return nil, err
+		}
+		rate, fee, creditedAmount = price.rate, price.fee, price.exchangedAmount
+	}
+
+	// the money leaves the wallet like a withdrawal, and a conversion is an exchange as well
+	withdrawLimit := w.limits.For(models.WithdrawTransaction, from)
+	var exchangeLimit models.Limit
+	if from != to {
+		exchangeLimit = w.limits.For(models.ExchangeTransaction, from)
+	}
+	if !withdrawLimit.AllowsOperation(amount) || !exchangeLimit.AllowsOperation(amount) {
+		return nil, errs.LimitExceeded
+	}
+
+	recipientUser, err := w.accounts.GetUserByNameOrEmail(ctx, recipient)
+	if err != nil {
+		if errors.Is(err, errs.UserNotExists) {
+			return nil, errs.RecipientNotExists
+		}
+		return nil, fmt.Errorf("failed to find recipient: %w", err)
+	}
+
+	recipientID := strconv.FormatInt(recipientUser.ID, 10)
+	if recipientID == userID {
+		return nil, errs.InvalidRecipient
+	}
+
+	balance, err := w.accounts.TransferAccountAmountWithBalance(ctx, userID, recipientID, from, amount,
+		to, creditedAmount, rate, fee, withdrawLimit, exchangeLimit)
 	if err != nil {
 		return nil, err
 	}
-	return &BalanceInfo{Accounts: balance}, nil
+	return &TransferInfo{Accounts: balance, CreditedAmount: creditedAmount, Rate: rate, Fee: fee}, nil
 }
 
 func (w *WalletService) GetBalance(ctx context.Context, userID string) (*BalanceInfo, error) {
@@ -160,7 +406,67 @@ func (w *WalletService) GetBalance(ctx context.Context, userID string) (*Balance
 	return &BalanceInfo{Accounts: balance}, err
 }
 
-func (w *WalletService) getExchangeRate(ctx context.Context, from models.Currency, to models.Currency) (float64, error) {
+func (w *WalletService) GetTransactions(ctx context.Context, userID string,
+	filter models.TransactionFilter) (*TransactionsPage, error) {
+
+	ctx, span := tracing.GetTracer().Start(ctx, "GetTransactions")
+	defer span.End()
+
+	if filter.Currency != "" && !filter.Currency.IsValid() {
+		return nil, errs.InvalidCurrency
+	}
+
+	if filter.Type != "" && !filter.Type.IsValid() {
+		return nil, errs.InvalidFilter
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, errs.InvalidFilter
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultTransactionsLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxTransactionsLimit || filter.BeforeID < 0 {
+		return nil, errs.InvalidFilter
+	}
+
+	limit := filter.Limit
+	filter.Limit++ // one extra row tells whether there is a next page
+
+	transactions, err := w.accounts.GetTransactions(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &TransactionsPage{Transactions: transactions}
+	if len(transactions) > limit {
+		page.Transactions = transactions[:limit]
+		page.NextCursor = transactions[limit-1].ID
+	}
+	return page, nil
+}
+
+// checkActive fails with a dedicated error unless the user may move money. Moving money checks the status
+// again in the transaction that moves it, this only refuses early what would fail there.
+func (w *WalletService) checkActive(ctx context.Context, userID string) error {
+
+	status, err := w.accounts.GetUserStatus(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user status: %w", err)
+	}
+
+	switch status {
+	case models.UserActive:
+		return nil
+	case models.UserFrozen:
+		return errs.UserFrozen
+	default:
+		return errs.UserClosed
+	}
+}
+
+func (w *WalletService) getExchangeRate(ctx context.Context, from models.Currency, to models.Currency) (decimal.Decimal, error) {
 
 	ctx, span := tracing.GetTracer().Start(ctx, "getExchangeRate")
 	defer span.End()
@@ -170,7 +476,7 @@ func (w *WalletService) getExchangeRate(ctx context.Context, from models.Currenc
 		if errors.Is(err, errs.KeyNotExists) {
 			slog.Debug("key does not exist")
 		} else {
-			return 0, fmt.Errorf("failed to get rate from cache: %w", err)
+			return decimal.Zero, fmt.Errorf("failed to get rate from cache: %w", err)
 		}
 	} else {
 		return rate, nil
@@ -178,7 +484,7 @@ func (w *WalletService) getExchangeRate(ctx context.Context, from models.Currenc
 
 	rate, err = w.exchangerClient.GetExchangeRateForOne(ctx, from, to)
 	if err != nil {
-		return 0, err
+		return decimal.Zero, err
 	}
 
 	err = w.redis.StoreRate(ctx, from, to, rate, w.ratesExpiration)
@@ -188,3 +494,9 @@ func (w *WalletService) getExchangeRate(ctx context.Context, from models.Currenc
 
 	return rate, nil
 }
+
+// convert applies rate to amount and rounds the result down to the scale of the target currency,
+// so an exchange never credits more than the rate allows.
+func convert(amount decimal.Decimal, rate decimal.Decimal, to models.Currency) decimal.Decimal {
+	return amount.Mul(rate).RoundDown(to.Scale())
+}
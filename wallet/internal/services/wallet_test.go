@@ -0,0 +1,28 @@
+package services
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"test-task/wallet/internal/domain/models"
+	"testing"
+)
+
+func Test_Convert_ShouldRoundDownToTargetScale(t *testing.T) {
+
+	amount := decimal.RequireFromString("10")
+	rate := decimal.RequireFromString("0.8499999")
+
+	converted := convert(amount, rate, models.EUR)
+
+	assert.Equal(t, "8.49", converted.StringFixed(models.EUR.Scale()))
+}
+
+func Test_Convert_ShouldKeepExactProduct(t *testing.T) {
+
+	amount := decimal.RequireFromString("0.1")
+	rate := decimal.RequireFromString("85")
+
+	converted := convert(amount, rate, models.RUB)
+
+	assert.True(t, decimal.RequireFromString("8.5").Equal(converted))
+}
@@ -0,0 +1,185 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"strconv"
+	errs "test-task/wallet/internal/domain/errors"
+	"test-task/wallet/internal/domain/models"
+)
+
+// AddUserRole grants the role to the user and records the entry in the same transaction.
+func (p *Storage) AddUserRole(ctx context.Context, userID int64, role models.Role, entry *models.AuditEntry) error {
+
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE users SET roles = array_append(roles, $2)
+        WHERE id = $1 AND NOT ($2 = ANY(roles))`, userID, string(role))
+	if err != nil {
+		return fmt.Errorf("failed to add user role: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+
+	if err = p.addAuditEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// SetUserStatus moves the user to the status and records the entry in the same transaction.
+// Closing is final, so the status of a closed user can't be changed.
+func (p *Storage) SetUserStatus(ctx context.Context, userID int64, status models.UserStatus,
+	entry *models.AuditEntry) error {
+
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var current string
+	err = tx.QueryRow(ctx, "SELECT status FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&current)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errs.UserNotExists
+		}
+		return fmt.Errorf("failed to get user status: %w", err)
+	}
+	if models.UserStatus(current) == models.UserClosed {
+		return errs.UserClosed
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE users SET status = $2 WHERE id = $1", userID, string(status))
+	if err != nil {
+		return fmt.Errorf("failed to set user status: %w", err)
+	}
+
+	entry.Details["from"] = current
+	entry.Details["to"] = string(status)
+	if err = p.addAuditEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// AdjustAccountAmountWithBalance applies delta to the account as an adjustment transaction and records
+// the entry, which refers to the transaction, in the same transaction. A debit may not overdraw the account.
+func (p *Storage) AdjustAccountAmountWithBalance(ctx context.Context, userID string, currency models.Currency,
+	delta decimal.Decimal, entry *models.AuditEntry) (map[models.Currency]decimal.Decimal, error) {
+
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	newAmount, err := p.changeAccountAmount(ctx, tx, userID, currency, delta)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := p.addTransaction(ctx, tx, userID, &models.Transaction{
+		Type:         models.AdjustmentTransaction,
+		Currency:     currency,
+		Amount:       delta,
+		BalanceAfter: newAmount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Details["transaction_id"] = strconv.FormatInt(id, 10)
+	if err = p.addAuditEntry(ctx, tx, entry); err != nil {
+		return nil, err
+	}
+
+	balance, err := p.getBalance(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return balance, tx.Commit(ctx)
+}
+
+func (p *Storage) AddAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	return p.addAuditEntry(ctx, p.pool, entry)
+}
+
+func (p *Storage) addAuditEntry(ctx context.Context, executor executor, entry *models.AuditEntry) error {
+
+	details, err := json.Marshal(entry.Details)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit details: %w", err)
+	}
+
+	err = executor.QueryRow(ctx, `INSERT INTO audit_log (admin_id, action, user_id, reason, details, trace_id)
+        VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`, entry.AdminID, string(entry.Action),
+		entry.UserID, entry.Reason, details, traceID(ctx)).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add audit entry: %w", err)
+	}
+	return nil
+}
+
+func (p *Storage) GetAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+
+	query := `SELECT id, admin_id, action, user_id, reason, details, COALESCE(trace_id, ''), created_at
+    FROM audit_log WHERE TRUE`
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		query += fmt.Sprintf(" AND %s $%d", condition, len(args))
+	}
+
+	if filter.AdminID != 0 {
+		addCondition("admin_id =", filter.AdminID)
+	}
+	if filter.UserID != 0 {
+		addCondition("user_id =", filter.UserID)
+	}
+	if filter.BeforeID != 0 {
+		addCondition("id <", filter.BeforeID)
+	}
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var e models.AuditEntry
+		var action string
+		var details []byte
+
+		err = rows.Scan(&e.ID, &e.AdminID, &action, &e.UserID, &e.Reason, &details, &e.TraceID, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if err = json.Unmarshal(details, &e.Details); err != nil {
+			return nil, fmt.Errorf("failed to decode audit details: %w", err)
+		}
+		e.Action = models.AuditAction(action)
+		entries = append(entries, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows during get audit entries: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"test-task/wallet/internal/domain/models"
+	"time"
+)
+
+func (p *Storage) AddSigningKey(ctx context.Context, key *models.SigningKey) error {
+	_, err := p.pool.Exec(ctx, "INSERT INTO signing_keys (id, algorithm, private_key, created_at) VALUES ($1, $2, $3, $4)",
+		key.ID, key.Algorithm, key.PrivateKey, key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add signing key: %w", err)
+	}
+	return nil
+}
+
+func (p *Storage) GetSigningKeys(ctx context.Context, createdAfter time.Time) ([]models.SigningKey, error) {
+
+	rows, err := p.pool.Query(ctx, `SELECT id, algorithm, private_key, created_at FROM signing_keys
+        WHERE created_at > $1 ORDER BY created_at, id`, createdAfter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signing keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []models.SigningKey
+	for rows.Next() {
+		var key models.SigningKey
+		if err = rows.Scan(&key.ID, &key.Algorithm, &key.PrivateKey, &key.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows during get signing keys: %w", err)
+	}
+	return keys, nil
+}
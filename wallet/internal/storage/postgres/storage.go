@@ -7,6 +7,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
 	errs "test-task/wallet/internal/domain/errors"
 	"test-task/wallet/internal/domain/models"
 )
@@ -43,43 +44,198 @@ func (p *Storage) AddUser(ctx context.Context, name string, password []byte, ema
 }
 
 func (p *Storage) GetUserByName(ctx context.Context, name string) (*models.User, error) {
+	return scanUser(p.pool.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE name = $1", name))
+}
+
+func (p *Storage) GetUserByNameOrEmail(ctx context.Context, login string) (*models.User, error) {
+	return scanUser(p.pool.QueryRow(ctx, "SELECT "+userColumns+` FROM users WHERE name = $1 OR email = $1
+        ORDER BY name = $1 DESC LIMIT 1`, login))
+}
+
+func (p *Storage) GetUserByID(ctx context.Context, id int64) (*models.User, error) {
+	return scanUser(p.pool.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1", id))
+}
+
+func (p *Storage) GetUserStatus(ctx context.Context, userID string) (models.UserStatus, error) {
+
+	var status string
+	err := p.pool.QueryRow(ctx, "SELECT status FROM users WHERE id = $1", userID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", errs.UserNotExists
+		}
+		return "", fmt.Errorf("failed to get user status: %w", err)
+	}
+	return models.UserStatus(status), nil
+}
+
+// lockUserStatus reads the status of the user and keeps it from changing until the transaction ends,
+// so that the user can't be frozen or closed while money is being moved.
+func (p *Storage) lockUserStatus(ctx context.Context, executor executor, userID string) (models.UserStatus, error) {
+
+	var status string
+	err := executor.QueryRow(ctx, "SELECT status FROM users WHERE id = $1 FOR SHARE", userID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", errs.UserNotExists
+		}
+		return "", fmt.Errorf("failed to get user status: %w", err)
+	}
+	return models.UserStatus(status), nil
+}
+
+// checkUserActive fails with a dedicated error unless the user may move money, see lockUserStatus.
+func (p *Storage) checkUserActive(ctx context.Context, executor executor, userID string) error {
+
+	status, err := p.lockUserStatus(ctx, executor, userID)
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case models.UserActive:
+		return nil
+	case models.UserFrozen:
+		return errs.UserFrozen
+	default:
+		return errs.UserClosed
+	}
+}
+
+// checkRecipientActive fails unless the recipient may be credited. A closed account is gone for other users.
+func (p *Storage) checkRecipientActive(ctx context.Context, executor executor, userID string) error {
+
+	status, err := p.lockUserStatus(ctx, executor, userID)
+	if err != nil {
+		if errors.Is(err, errs.UserNotExists) {
+			return errs.RecipientNotExists
+		}
+		return err
+	}
+
+	switch status {
+	case models.UserActive:
+		return nil
+	case models.UserFrozen:
+		return errs.RecipientFrozen
+	default:
+		return errs.RecipientNotExists
+	}
+}
+
+const userColumns = "id, name, password, email, roles, status"
+
+func scanUser(row pgx.Row) (*models.User, error) {
 
 	user := models.User{}
-	err := p.pool.QueryRow(ctx, "SELECT id, name, password, email FROM users WHERE name = $1",
-		name).Scan(&user.ID, &user.Name, &user.Password, &user.Email)
+	var roles []string
+	var status string
+	err := row.Scan(&user.ID, &user.Name, &user.Password, &user.Email, &roles, &status)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, errs.UserNotExists
 		}
 		return nil, fmt.Errorf("failed to check user existance in DB: %w", err)
 	}
+	user.Status = models.UserStatus(status)
 
+	for _, role := range roles {
+		user.Roles = append(user.Roles, models.Role(role))
+	}
 	return &user, nil
 }
 
-func (p *Storage) ExchangeAccountAmount(ctx context.Context, userID string, from models.Currency,
-	fromAmount float64, to models.Currency, toAmount float64) error {
+// TransferAccountAmountWithBalance debits fromAmount in the from currency of the sender and credits toAmount
+// in the to currency of the recipient. When the currencies differ, the rate is recorded on both legs and the fee,
+// a part of fromAmount, is moved to the revenue account of the from currency. The debit must fit into the volume
+// limit of withdrawals and, when converted, into that of exchanges as well.
+func (p *Storage) TransferAccountAmountWithBalance(ctx context.Context, fromUserID string, toUserID string,
+	from models.Currency, fromAmount decimal.Decimal, to models.Currency, toAmount decimal.Decimal,
+	rate decimal.Decimal, fee decimal.Decimal, withdrawLimit models.Limit,
+	exchangeLimit models.Limit) (map[models.Currency]decimal.Decimal, error) {
 
 	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer tx.Rollback(ctx)
 
-	err = p.changeAccountAmount(ctx, tx, userID, from, -fromAmount)
+	if err = p.checkUserActive(ctx, tx, fromUserID); err != nil {
+		return nil, err
+	}
+	if err = p.checkRecipientActive(ctx, tx, toUserID); err != nil {
+		return nil, err
+	}
+
+	// lock both accounts in id order, so that opposite transfers between the same users cannot deadlock
+	_, err = tx.Exec(ctx, `SELECT id FROM accounts WHERE (user_id = $1 AND currency = $2) OR (user_id = $3 AND currency = $4)
+        ORDER BY id FOR UPDATE`, fromUserID, string(from), toUserID, string(to))
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to lock accounts: %w", err)
 	}
 
-	err = p.changeAccountAmount(ctx, tx, userID, to, toAmount)
+	fromBalance, err := p.changeAccountAmount(ctx, tx, fromUserID, from, fromAmount.Neg())
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	toBalance, err := p.changeAccountAmount(ctx, tx, toUserID, to, toAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	fromLeg := models.Transaction{
+		Type:         models.TransferTransaction,
+		Currency:     from,
+		Amount:       fromAmount.Neg(),
+		BalanceAfter: fromBalance,
 	}
-	return tx.Commit(ctx)
+	toLeg := models.Transaction{
+		Type:         models.TransferTransaction,
+		Currency:     to,
+		Amount:       toAmount,
+		BalanceAfter: toBalance,
+	}
+	if from != to {
+		fromLeg.Rate = &rate
+		fromLeg.Fee = &fee
+		toLeg.Rate = &rate
+	}
+	err = p.addLinkedTransactions(ctx, tx, fromUserID, &fromLeg, toUserID, &toLeg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.checkVolumeLimit(ctx, tx, fromUserID, models.WithdrawTransaction, from, withdrawLimit)
+	if err != nil {
+		return nil, err
+	}
+	if from != to {
+		err = p.checkVolumeLimit(ctx, tx, fromUserID, models.ExchangeTransaction, from, exchangeLimit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if fee.IsPositive() {
+		if err = p.addRevenue(ctx, tx, from, fee); err != nil {
+			return nil, err
+		}
+	}
+
+	balance, err := p.getBalance(ctx, tx, fromUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return balance, tx.Commit(ctx)
 }
 
+// ExchangeAccountAmountWithBalance debits fromAmount and credits toAmount. The fee is a part of fromAmount
+// and is moved to the revenue account of the from currency. The debit must fit into the volume limit.
 func (p *Storage) ExchangeAccountAmountWithBalance(ctx context.Context, userID string, from models.Currency,
-	fromAmount float64, to models.Currency, toAmount float64) (map[models.Currency]float64, error) {
+	fromAmount decimal.Decimal, to models.Currency, toAmount decimal.Decimal,
+	rate decimal.Decimal, fee decimal.Decimal, limit models.Limit) (map[models.Currency]decimal.Decimal, error) {
 
 	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
 	if err != nil {
@@ -87,16 +243,51 @@ func (p *Storage) ExchangeAccountAmountWithBalance(ctx context.Context, userID s
 	}
 	defer tx.Rollback(ctx)
 
-	err = p.changeAccountAmount(ctx, tx, userID, from, -fromAmount)
+	if err = p.checkUserActive(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	fromBalance, err := p.changeAccountAmount(ctx, tx, userID, from, fromAmount.Neg())
 	if err != nil {
 		return nil, err
 	}
 
-	err = p.changeAccountAmount(ctx, tx, userID, to, toAmount)
+	toBalance, err := p.changeAccountAmount(ctx, tx, userID, to, toAmount)
 	if err != nil {
 		return nil, err
 	}
 
+	fromLeg := models.Transaction{
+		Type:         models.ExchangeTransaction,
+		Currency:     from,
+		Amount:       fromAmount.Neg(),
+		BalanceAfter: fromBalance,
+		Rate:         &rate,
+		Fee:          &fee,
+	}
+	toLeg := models.Transaction{
+		Type:         models.ExchangeTransaction,
+		Currency:     to,
+		Amount:       toAmount,
+		BalanceAfter: toBalance,
+		Rate:         &rate,
+	}
+	err = p.addLinkedTransactions(ctx, tx, userID, &fromLeg, userID, &toLeg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.checkVolumeLimit(ctx, tx, userID, models.ExchangeTransaction, from, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if fee.IsPositive() {
+		if err = p.addRevenue(ctx, tx, from, fee); err != nil {
+			return nil, err
+		}
+	}
+
 	balance, err := p.getBalance(ctx, tx, userID)
 	if err != nil {
 		return nil, err
@@ -105,12 +296,10 @@ func (p *Storage) ExchangeAccountAmountWithBalance(ctx context.Context, userID s
 	return balance, tx.Commit(ctx)
 }
 
-func (p *Storage) ChangeAccountAmount(ctx context.Context, userID string, currency models.Currency, delta float64) error {
-	return p.changeAccountAmount(ctx, p.pool, userID, currency, delta)
-}
-
-func (p *Storage) ChangeAccountAmountWithBalance(ctx context.Context, userID string,
-	currency models.Currency, delta float64) (map[models.Currency]float64, error) {
+// ChangeAccountAmountWithBalance applies delta to the account. A debit must fit into the volume limit.
+func (p *Storage) ChangeAccountAmountWithBalance(ctx context.Context, userID string, currency models.Currency,
+	delta decimal.Decimal, transactionType models.TransactionType,
+	limit models.Limit) (map[models.Currency]decimal.Decimal, error) {
 
 	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
 	if err != nil {
@@ -118,11 +307,32 @@ func (p *Storage) ChangeAccountAmountWithBalance(ctx context.Context, userID str
 	}
 	defer tx.Rollback(ctx)
 
-	err = p.changeAccountAmount(ctx, tx, userID, currency, delta)
+	if err = p.checkUserActive(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	newAmount, err := p.changeAccountAmount(ctx, tx, userID, currency, delta)
 	if err != nil {
 		return nil, err
 	}
 
+	_, err = p.addTransaction(ctx, tx, userID, &models.Transaction{
+		Type:         transactionType,
+		Currency:     currency,
+		Amount:       delta,
+		BalanceAfter: newAmount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if delta.IsNegative() {
+		err = p.checkVolumeLimit(ctx, tx, userID, transactionType, currency, limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	balance, err := p.getBalance(ctx, tx, userID)
 	if err != nil {
 		return nil, err
@@ -131,13 +341,13 @@ func (p *Storage) ChangeAccountAmountWithBalance(ctx context.Context, userID str
 	return balance, tx.Commit(ctx)
 }
 
-func (p *Storage) GetBalance(ctx context.Context, userID string) (map[models.Currency]float64, error) {
+func (p *Storage) GetBalance(ctx context.Context, userID string) (map[models.Currency]decimal.Decimal, error) {
 	return p.getBalance(ctx, p.pool, userID)
 }
 
-func (p *Storage) getBalance(ctx context.Context, executor executor, userID string) (map[models.Currency]float64, error) {
+func (p *Storage) getBalance(ctx context.Context, executor executor, userID string) (map[models.Currency]decimal.Decimal, error) {
 
-	res := make(map[models.Currency]float64)
+	res := make(map[models.Currency]decimal.Decimal)
 
 	query := "SELECT currency, amount from accounts WHERE user_id = $1"
 	rows, err := executor.Query(ctx, query, userID)
@@ -148,7 +358,7 @@ func (p *Storage) getBalance(ctx context.Context, executor executor, userID stri
 
 	for rows.Next() {
 		var currency string
-		var amount float64
+		var amount decimal.Decimal
 
 		err = rows.Scan(&currency, &amount)
 		if err != nil {
@@ -163,24 +373,36 @@ func (p *Storage) getBalance(ctx context.Context, executor executor, userID stri
 	return res, nil
 }
 
-func (p *Storage) changeAccountAmount(ctx context.Context, executor executor, userID string, currency models.Currency, delta float64) error {
+func (p *Storage) changeAccountAmount(ctx context.Context, executor executor, userID string,
+	currency models.Currency, delta decimal.Decimal) (decimal.Decimal, error) {
 
-	query := "UPDATE accounts SET amount = amount + $3 WHERE user_id = $1 AND currency = $2"
-	if delta > 0 {
-		query = "INSERT INTO accounts (user_id, currency, amount) VALUES ($1, $2, $3) ON CONFLICT (user_id, currency) DO UPDATE SET amount = accounts.amount + $3;"
+	query := "UPDATE accounts SET amount = amount + $3 WHERE user_id = $1 AND currency = $2 RETURNING amount"
+	if delta.IsPositive() {
+		query = "INSERT INTO accounts (user_id, currency, amount) VALUES ($1, $2, $3) ON CONFLICT (user_id, currency) DO UPDATE SET amount = accounts.amount + $3 RETURNING amount"
 	}
 
-	res, err := executor.Exec(ctx, query, userID, string(currency), delta)
+	var newAmount decimal.Decimal
+	err := executor.QueryRow(ctx, query, userID, string(currency), delta).Scan(&newAmount)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return decimal.Zero, errs.InsufficientFunds
+		}
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23514" {
-			return errs.InsufficientFunds
+			return decimal.Zero, errs.InsufficientFunds
 		}
-		return fmt.Errorf("failed to change amount in DB: %w", err)
+		return decimal.Zero, fmt.Errorf("failed to change amount in DB: %w", err)
 	}
+	return newAmount, nil
+}
+
+func (p *Storage) addRevenue(ctx context.Context, executor executor, currency models.Currency,
+	amount decimal.Decimal) error {
 
-	if res.RowsAffected() == 0 {
-		return errs.InsufficientFunds
+	_, err := executor.Exec(ctx, `INSERT INTO revenue_accounts (currency, amount) VALUES ($1, $2)
+        ON CONFLICT (currency) DO UPDATE SET amount = revenue_accounts.amount + $2`, string(currency), amount)
+	if err != nil {
+		return fmt.Errorf("failed to book fee: %w", err)
 	}
 	return nil
 }
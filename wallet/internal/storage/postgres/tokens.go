@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	errs "test-task/wallet/internal/domain/errors"
+	"test-task/wallet/internal/domain/models"
+	"time"
+)
+
+func (p *Storage) AddRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	return p.addRefreshToken(ctx, p.pool, token)
+}
+
+func (p *Storage) addRefreshToken(ctx context.Context, executor executor, token *models.RefreshToken) error {
+	_, err := executor.Exec(ctx, `INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+        VALUES ($1, $2, $3, $4)`, token.UserID, token.FamilyID, token.Hash, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to add refresh token: %w", err)
+	}
+	return nil
+}
+
+// RotateRefreshToken marks the token with the given hash as used and stores next in its family,
+// filling the user and the family of next. If the token was already used, the whole family is revoked
+// and errs.RefreshTokenReused is returned.
+func (p *Storage) RotateRefreshToken(ctx context.Context, hash []byte, next *models.RefreshToken) error {
+
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var expiresAt time.Time
+	var usedAt, revokedAt *time.Time
+	err = tx.QueryRow(ctx, `SELECT user_id, family_id, expires_at, used_at, revoked_at FROM refresh_tokens
+        WHERE token_hash = $1 FOR UPDATE`, hash).Scan(&next.UserID, &next.FamilyID, &expiresAt, &usedAt, &revokedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errs.InvalidRefreshToken
+		}
+		return fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if revokedAt != nil {
+		return errs.InvalidRefreshToken
+	}
+
+	if usedAt != nil {
+		_, err = tx.Exec(ctx, "UPDATE refresh_tokens SET revoked_at = now() WHERE family_id = $1 AND revoked_at IS NULL",
+			next.FamilyID)
+		if err != nil {
+			return fmt.Errorf("failed to revoke token family: %w", err)
+		}
+		if err = tx.Commit(ctx); err != nil {
+			return err
+		}
+		return errs.RefreshTokenReused
+	}
+
+	if !time.Now().Before(expiresAt) {
+		return errs.InvalidRefreshToken
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE refresh_tokens SET used_at = now() WHERE token_hash = $1", hash)
+	if err != nil {
+		return fmt.Errorf("failed to use refresh token: %w", err)
+	}
+
+	if err = p.addRefreshToken(ctx, tx, next); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RevokeRefreshTokenFamily revokes the family of the user's token with the given hash.
+func (p *Storage) RevokeRefreshTokenFamily(ctx context.Context, userID string, hash []byte) error {
+	_, err := p.pool.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = now() WHERE revoked_at IS NULL AND family_id =
+        (SELECT family_id FROM refresh_tokens WHERE token_hash = $2 AND user_id = $1)`, userID, hash)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token family: %w", err)
+	}
+	return nil
+}
+
+func (p *Storage) RevokeUserRefreshTokens(ctx context.Context, userID string) error {
+	_, err := p.pool.Exec(ctx, "UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL",
+		userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
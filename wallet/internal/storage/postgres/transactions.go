@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/shopspring/decimal"
+	errs "test-task/wallet/internal/domain/errors"
+	"test-task/wallet/internal/domain/models"
+	"test-task/wallet/internal/tracing"
+	"time"
+)
+
+const insertTransactionQuery = `INSERT INTO transactions
+    (id, user_id, type, currency, amount, balance_after, counterparty_id, rate, fee, trace_id)
+    VALUES (COALESCE($1, nextval('transactions_id_seq')), $2, $3, $4, $5, $6, $7, $8, $9, $10)
+    RETURNING id, created_at`
+
+func (p *Storage) addTransaction(ctx context.Context, executor executor, userID string,
+	transaction *models.Transaction) (int64, error) {
+
+	var id *int64
+	if transaction.ID != 0 {
+		id = &transaction.ID
+	}
+
+	err := executor.QueryRow(ctx, insertTransactionQuery, id, userID, string(transaction.Type),
+		string(transaction.Currency), transaction.Amount, transaction.BalanceAfter, transaction.CounterpartyID,
+		transaction.Rate, transaction.Fee, traceID(ctx)).Scan(&transaction.ID, &transaction.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add transaction: %w", err)
+	}
+	return transaction.ID, nil
+}
+
+// addLinkedTransactions writes two legs of one operation, each referencing the other as its counterparty.
+// Ids are reserved up front so that both rows can be inserted without updating the append-only table.
+func (p *Storage) addLinkedTransactions(ctx context.Context, executor executor, firstUserID string,
+	first *models.Transaction, secondUserID string, second *models.Transaction) error {
+
+	err := executor.QueryRow(ctx, "SELECT nextval('transactions_id_seq'), nextval('transactions_id_seq')").
+		Scan(&first.ID, &second.ID)
+	if err != nil {
+		return fmt.Errorf("failed to reserve transaction ids: %w", err)
+	}
+
+	first.CounterpartyID = &second.ID
+	second.CounterpartyID = &first.ID
+
+	// the counterparty of the first leg does not exist yet, so the constraint is checked at commit
+	_, err = executor.Exec(ctx, "SET CONSTRAINTS transactions_counterparty_id_fkey DEFERRED")
+	if err != nil {
+		return fmt.Errorf("failed to defer counterparty constraint: %w", err)
+	}
+
+	if _, err = p.addTransaction(ctx, executor, firstUserID, first); err != nil {
+		return err
+	}
+	if _, err = p.addTransaction(ctx, executor, secondUserID, second); err != nil {
+		return err
+	}
+	return nil
+}
+
+func traceID(ctx context.Context) *string {
+	info, err := tracing.GetTraceInfo(ctx)
+	if err != nil {
+		return nil
+	}
+	return &info.TraceID
+}
+
+func (p *Storage) GetTransactions(ctx context.Context, userID string,
+	filter models.TransactionFilter) ([]models.Transaction, error) {
+
+	query := `SELECT id, user_id, type, currency, amount, balance_after, counterparty_id, rate, fee, COALESCE(trace_id, ''), created_at
+    FROM transactions WHERE user_id = $1`
+	args := []any{userID}
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		query += fmt.Sprintf(" AND %s $%d", condition, len(args))
+	}
+
+	if filter.Currency != "" {
+		addCondition("currency =", string(filter.Currency))
+	}
+	if filter.Type != "" {
+		addCondition("type =", string(filter.Type))
+	}
+	if !filter.From.IsZero() {
+		addCondition("created_at >=", filter.From)
+	}
+	if !filter.To.IsZero() {
+		addCondition("created_at <", filter.To)
+	}
+	if filter.BeforeID != 0 {
+		addCondition("id <", filter.BeforeID)
+	}
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []models.Transaction
+	for rows.Next() {
+		var t models.Transaction
+		var transactionType, currency string
+
+		err = rows.Scan(&t.ID, &t.UserID, &transactionType, &currency, &t.Amount, &t.BalanceAfter,
+			&t.CounterpartyID, &t.Rate, &t.Fee, &t.TraceID, &t.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		t.Type = models.TransactionType(transactionType)
+		t.Currency = models.Currency(currency)
+		transactions = append(transactions, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows during get transactions: %w", err)
+	}
+	return transactions, nil
+}
+
+// volumeConditions select the debits that count towards the limit of a transaction type besides those of
+// the type itself: money sent to another user leaves the wallet like a withdrawal, and is exchanged as well
+// when it is converted on the way.
+var volumeConditions = map[models.TransactionType]string{
+	models.WithdrawTransaction: "(type = $2 OR type = 'transfer')",
+	models.ExchangeTransaction: "(type = $2 OR (type = 'transfer' AND rate IS NOT NULL))",
+}
+
+func volumeCondition(transactionType models.TransactionType) string {
+	if condition, ok := volumeConditions[transactionType]; ok {
+		return condition
+	}
+	return "type = $2"
+}
+
+// checkVolumeLimit sums up today's and this month's debits that count towards the limit of the given type,
+// including the ones made in the current transaction, and returns errs.LimitExceeded if the limit is exceeded.
+// It must be called after the account is updated, so that the account row lock serializes the check.
+func (p *Storage) checkVolumeLimit(ctx context.Context, executor executor, userID string,
+	transactionType models.TransactionType, currency models.Currency, limit models.Limit) error {
+
+	if !limit.HasVolume() {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var daily, monthly decimal.Decimal
+	err := executor.QueryRow(ctx, `SELECT COALESCE(SUM(-amount) FILTER (WHERE created_at >= $4), 0),
+        COALESCE(SUM(-amount), 0)
+    FROM transactions WHERE user_id = $1 AND `+volumeCondition(transactionType)+`
+        AND currency = $3 AND amount < 0 AND created_at >= $5`,
+		userID, string(transactionType), string(currency), dayStart, monthStart).Scan(&daily, &monthly)
+	if err != nil {
+		return fmt.Errorf("failed to get debited volume: %w", err)
+	}
+
+	if !limit.AllowsVolume(daily, monthly) {
+		return errs.LimitExceeded
+	}
+	return nil
+}
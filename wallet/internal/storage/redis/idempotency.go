@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"test-task/wallet/internal/domain/models"
+	"time"
+)
+
+func idempotencyKey(userID, key string) string {
+	return "idempotency:" + userID + ":" + key
+}
+
+// ReserveIdempotencyKey stores a pending record for the key unless one already exists.
+// It returns the existing record when the key has been seen before, or nil when the caller now owns the key.
+func (c *Redis) ReserveIdempotencyKey(ctx context.Context, userID, key, fingerprint string,
+	lifetime time.Duration) (*models.IdempotencyRecord, error) {
+
+	data, err := json.Marshal(models.IdempotencyRecord{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+
+	// one step, so that a key expiring in between can't be missed
+	existing, err := c.client.SetArgs(ctx, idempotencyKey(userID, key), data,
+		redis.SetArgs{Mode: "NX", TTL: lifetime, Get: true}).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var record models.IdempotencyRecord
+	if err = json.Unmarshal(existing, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse idempotency record: %w", err)
+	}
+	return &record, nil
+}
+
+func (c *Redis) CompleteIdempotencyKey(ctx context.Context, userID, key string, record models.IdempotencyRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	err = c.client.SetArgs(ctx, idempotencyKey(userID, key), data, redis.SetArgs{KeepTTL: true, Mode: "XX"}).Err()
+	if errors.Is(err, redis.Nil) {
+		return nil // the key has already expired
+	}
+	return err
+}
+
+// releaseIdempotencyKeyScript deletes the key only while it holds the pending record of the caller.
+var releaseIdempotencyKeyScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+    return redis.call('DEL', KEYS[1])
+end
+return 0`)
+
+// ReleaseIdempotencyKey removes the pending record of a request with the fingerprint. A record that has
+// been completed, or that another request reserved after this one expired, is left alone.
+func (c *Redis) ReleaseIdempotencyKey(ctx context.Context, userID, key, fingerprint string) error {
+	data, err := json.Marshal(models.IdempotencyRecord{Fingerprint: fingerprint})
+	if err != nil {
+		return err
+	}
+	return releaseIdempotencyKeyScript.Run(ctx, c.client, []string{idempotencyKey(userID, key)}, data).Err()
+}
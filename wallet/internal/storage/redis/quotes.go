@@ -0,0 +1,41 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	errs "test-task/wallet/internal/domain/errors"
+	"test-task/wallet/internal/domain/models"
+	"time"
+)
+
+func quoteKey(userID, id string) string {
+	return "quote:" + userID + ":" + id
+}
+
+func (c *Redis) StoreQuote(ctx context.Context, quote *models.Quote, expiration time.Duration) error {
+	data, err := json.Marshal(quote)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, quoteKey(quote.UserID, quote.ID), data, expiration).Err()
+}
+
+// TakeQuote returns the quote and removes it, so that each quote can be executed only once.
+func (c *Redis) TakeQuote(ctx context.Context, userID, id string) (*models.Quote, error) {
+	data, err := c.client.GetDel(ctx, quoteKey(userID, id)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errs.KeyNotExists
+		}
+		return nil, err
+	}
+
+	var quote models.Quote
+	if err = json.Unmarshal(data, &quote); err != nil {
+		return nil, fmt.Errorf("failed to parse quote: %w", err)
+	}
+	return &quote, nil
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
 	"strconv"
+	"strings"
 	errs "test-task/wallet/internal/domain/errors"
 	"test-task/wallet/internal/domain/models"
 	"time"
@@ -39,39 +41,104 @@ func New(config Config) (*Redis, error) {
 	return &Redis{client: client}, nil
 }
 
-func (c *Redis) StoreRate(ctx context.Context, from models.Currency, to models.Currency, value float64, expiration time.Duration) error {
-	res := c.client.Set(ctx, string(from)+"/"+string(to), value, expiration)
+func (c *Redis) StoreRate(ctx context.Context, from models.Currency, to models.Currency, value decimal.Decimal, expiration time.Duration) error {
+	res := c.client.Set(ctx, string(from)+"/"+string(to), value.String(), expiration)
 	return res.Err()
 }
 
-func (c *Redis) StoreRates(ctx context.Context, rates map[models.Currency]float64, base models.Currency, expiration time.Duration) error {
+// the rates are kept in one hash along with their metadata, under fields that cannot be currency codes
+const (
+	ratesKey            = "rates"
+	ratesBaseField      = "_base"
+	ratesVersionField   = "_version"
+	ratesEffectiveField = "_effective_at"
+)
+
+func (c *Redis) StoreRates(ctx context.Context, rates *models.ExchangeRates, expiration time.Duration) error {
 
-	key := "rates:" + string(base)
-	data := make(map[string]interface{})
-	for currency, value := range rates {
-		data[string(currency)] = value
+	err := c.client.HSet(ctx, ratesKey, ratesData(rates)).Err()
+	if err != nil {
+		return err
 	}
 
-	err := c.client.HSet(ctx, key, data).Err()
+	return c.client.Expire(ctx, ratesKey, expiration).Err()
+}
+
+// ReplaceRates stores fresh rates in place of the cached ones and drops the cached rates
+// of currency pairs, so that they are fetched again.
+func (c *Redis) ReplaceRates(ctx context.Context, rates *models.ExchangeRates, expiration time.Duration) error {
+
+	// currencies that are no longer in the map could still have cached pair rates
+	cached, err := c.client.HKeys(ctx, ratesKey).Result()
 	if err != nil {
 		return err
 	}
 
-	return c.client.Expire(ctx, key, expiration).Err()
+	currencies := make(map[string]struct{})
+	for _, field := range cached {
+		if !strings.HasPrefix(field, "_") {
+			currencies[field] = struct{}{}
+		}
+	}
+	for currency := range rates.Rates {
+		currencies[string(currency)] = struct{}{}
+	}
+
+	var pairs []string
+	for from := range currencies {
+		for to := range currencies {
+			if from != to {
+				pairs = append(pairs, from+"/"+to)
+			}
+		}
+	}
+
+	_, err = c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, ratesKey)
+		if len(pairs) > 0 {
+			pipe.Del(ctx, pairs...)
+		}
+		if len(rates.Rates) > 0 {
+			pipe.HSet(ctx, ratesKey, ratesData(rates))
+			pipe.Expire(ctx, ratesKey, expiration)
+		}
+		return nil
+	})
+	return err
+}
+
+func ratesData(rates *models.ExchangeRates) map[string]interface{} {
+
+	data := map[string]interface{}{
+		ratesBaseField:      string(rates.Base),
+		ratesVersionField:   rates.Version,
+		ratesEffectiveField: rates.EffectiveAt.Format(time.RFC3339Nano),
+	}
+	for currency, value := range rates.Rates {
+		data[string(currency)] = value.String()
+	}
+	return data
 }
 
-func (c *Redis) GetRate(ctx context.Context, from models.Currency, to models.Currency) (float64, error) {
-	res, err := c.client.Get(ctx, string(from)+"/"+string(to)).Float64()
+func (c *Redis) GetRate(ctx context.Context, from models.Currency, to models.Currency) (decimal.Decimal, error) {
+	res, err := c.client.Get(ctx, string(from)+"/"+string(to)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return decimal.Zero, errs.KeyNotExists
+		}
+		return decimal.Zero, err
+	}
 
-	if errors.Is(err, redis.Nil) {
-		return 0, errs.KeyNotExists
+	rate, err := decimal.NewFromString(res)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to parse cached rate: %w", err)
 	}
-	return res, nil
+	return rate, nil
 }
 
-func (c *Redis) GetRates(ctx context.Context, base models.Currency) (map[models.Currency]float64, error) {
+func (c *Redis) GetRates(ctx context.Context) (*models.ExchangeRates, error) {
 
-	res := c.client.HGetAll(ctx, "rates:"+string(base))
+	res := c.client.HGetAll(ctx, ratesKey)
 	if res.Err() != nil {
 		return nil, res.Err()
 	}
@@ -80,13 +147,25 @@ func (c *Redis) GetRates(ctx context.Context, base models.Currency) (map[models.
 		return nil, errs.KeyNotExists
 	}
 
-	rates := make(map[models.Currency]float64)
-	for currency, rateStr := range res.Val() {
-		rate, _ := strconv.ParseFloat(rateStr, 64)
-		rates[models.Currency(currency)] = rate
+	rates := models.ExchangeRates{Rates: make(map[models.Currency]decimal.Decimal)}
+	for field, value := range res.Val() {
+		var err error
+		switch field {
+		case ratesBaseField:
+			rates.Base = models.Currency(value)
+		case ratesVersionField:
+			rates.Version, err = strconv.ParseInt(value, 10, 64)
+		case ratesEffectiveField:
+			rates.EffectiveAt, err = time.Parse(time.RFC3339Nano, value)
+		default:
+			rates.Rates[models.Currency(field)], err = decimal.NewFromString(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse cached rates field %s: %w", field, err)
+		}
 	}
 
-	return rates, nil
+	return &rates, nil
 }
 
 func (c *Redis) Close(ctx context.Context) error {
@@ -0,0 +1,60 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+func revokedTokenKey(id string) string {
+	return "revoked:token:" + id
+}
+
+func revokedUserKey(userID string) string {
+	return "revoked:user:" + userID
+}
+
+// RevokeToken puts the token id on the revocation list. The entry may expire along with the token.
+func (c *Redis) RevokeToken(ctx context.Context, id string, expiration time.Duration) error {
+	if expiration <= 0 {
+		return nil // the token has already expired
+	}
+	return c.client.Set(ctx, revokedTokenKey(id), 1, expiration).Err()
+}
+
+// RevokeUserTokens revokes every token of the user issued at or before issuedBefore, keeping the entry
+// for as long as such tokens may live. The time is stored as seconds with milliseconds, the precision
+// of token times.
+func (c *Redis) RevokeUserTokens(ctx context.Context, userID string, issuedBefore time.Time,
+	expiration time.Duration) error {
+	return c.client.Set(ctx, revokedUserKey(userID), unixSeconds(issuedBefore).String(), expiration).Err()
+}
+
+func (c *Redis) IsTokenRevoked(ctx context.Context, userID, id string, issuedAt time.Time) (bool, error) {
+
+	values, err := c.client.MGet(ctx, revokedTokenKey(id), revokedUserKey(userID)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+
+	if values[0] != nil {
+		return true, nil
+	}
+	if values[1] == nil {
+		return false, nil
+	}
+
+	// entries written before token times got milliseconds hold whole seconds, which parse all the same
+	issuedBefore, err := decimal.NewFromString(values[1].(string))
+	if err != nil {
+		return false, err
+	}
+	return unixSeconds(issuedAt).LessThanOrEqual(issuedBefore), nil
+}
+
+// unixSeconds returns the Unix time in seconds with milliseconds.
+func unixSeconds(t time.Time) decimal.Decimal {
+	return decimal.New(t.UnixMilli(), -3)
+}
@@ -0,0 +1,195 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"strconv"
+	errs "test-task/wallet/internal/domain/errors"
+	"test-task/wallet/internal/domain/models"
+	"time"
+)
+
+// AddUserRole grants the role to the user and records the entry in the same transaction.
+func (s *Storage) AddUserRole(ctx context.Context, userID int64, role models.Role, entry *models.AuditEntry) error {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE users SET roles = roles || ',' || ?2
+        WHERE id = ?1 AND ',' || roles || ',' NOT LIKE '%,' || ?2 || ',%'`, userID, string(role))
+	if err != nil {
+		return fmt.Errorf("failed to add user role: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return nil
+	}
+
+	if err = s.addAuditEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetUserStatus moves the user to the status and records the entry in the same transaction.
+// Closing is final, so the status of a closed user can't be changed.
+func (s *Storage) SetUserStatus(ctx context.Context, userID int64, status models.UserStatus,
+	entry *models.AuditEntry) error {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current string
+	err = tx.QueryRowContext(ctx, "SELECT status FROM users WHERE id = ?", userID).Scan(&current)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.UserNotExists
+		}
+		return fmt.Errorf("failed to get user status: %w", err)
+	}
+	if models.UserStatus(current) == models.UserClosed {
+		return errs.UserClosed
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET status = ? WHERE id = ?", string(status), userID)
+	if err != nil {
+		return fmt.Errorf("failed to set user status: %w", err)
+	}
+
+	entry.Details["from"] = current
+	entry.Details["to"] = string(status)
+	if err = s.addAuditEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AdjustAccountAmountWithBalance applies delta to the account as an adjustment transaction and records
+// the entry, which refers to the transaction, in the same transaction. A debit may not overdraw the account.
+func (s *Storage) AdjustAccountAmountWithBalance(ctx context.Context, userID string, currency models.Currency,
+	delta decimal.Decimal, entry *models.AuditEntry) (map[models.Currency]decimal.Decimal, error) {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	newAmount, err := s.changeAccountAmount(ctx, tx, userID, currency, delta)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.addTransaction(ctx, tx, userID, &models.Transaction{
+		Type:         models.AdjustmentTransaction,
+		Currency:     currency,
+		Amount:       delta,
+		BalanceAfter: newAmount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Details["transaction_id"] = strconv.FormatInt(id, 10)
+	if err = s.addAuditEntry(ctx, tx, entry); err != nil {
+		return nil, err
+	}
+
+	balance, err := s.getBalance(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return balance, tx.Commit()
+}
+
+func (s *Storage) AddAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	return s.addAuditEntry(ctx, s.db, entry)
+}
+
+func (s *Storage) addAuditEntry(ctx context.Context, executor executor, entry *models.AuditEntry) error {
+
+	details, err := json.Marshal(entry.Details)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit details: %w", err)
+	}
+
+	createdAt := time.Now().UTC()
+	err = executor.QueryRowContext(ctx, `INSERT INTO audit_log
+        (admin_id, action, user_id, reason, details, trace_id, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id`, entry.AdminID, string(entry.Action), entry.UserID,
+		entry.Reason, string(details), traceID(ctx), formatTime(createdAt)).Scan(&entry.ID)
+	if err != nil {
+		return fmt.Errorf("failed to add audit entry: %w", err)
+	}
+	entry.CreatedAt = createdAt
+	return nil
+}
+
+func (s *Storage) GetAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+
+	query := `SELECT id, admin_id, action, user_id, reason, details, COALESCE(trace_id, ''), created_at
+    FROM audit_log WHERE TRUE`
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		query += fmt.Sprintf(" AND %s ?", condition)
+	}
+
+	if filter.AdminID != 0 {
+		addCondition("admin_id =", filter.AdminID)
+	}
+	if filter.UserID != 0 {
+		addCondition("user_id =", filter.UserID)
+	}
+	if filter.BeforeID != 0 {
+		addCondition("id <", filter.BeforeID)
+	}
+
+	args = append(args, filter.Limit)
+	query += " ORDER BY id DESC LIMIT ?"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var e models.AuditEntry
+		var action, details, createdAt string
+
+		err = rows.Scan(&e.ID, &e.AdminID, &action, &e.UserID, &e.Reason, &details, &e.TraceID, &createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if err = json.Unmarshal([]byte(details), &e.Details); err != nil {
+			return nil, fmt.Errorf("failed to decode audit details: %w", err)
+		}
+		if e.CreatedAt, err = time.Parse(timeLayout, createdAt); err != nil {
+			return nil, fmt.Errorf("failed to parse audit entry time: %w", err)
+		}
+		e.Action = models.AuditAction(action)
+		entries = append(entries, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows during get audit entries: %w", err)
+	}
+	return entries, nil
+}
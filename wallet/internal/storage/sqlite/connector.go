@@ -0,0 +1,51 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// pragmas are applied to every connection: foreign keys are off in SQLite by default.
+const pragmas = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+
+type Connector struct {
+	db *sql.DB
+}
+
+// NewConnector opens the database file of dsn, e.g. file:wallet.db.
+// SQLite allows a single writer, so all queries share one connection and transactions are serialized.
+func NewConnector(ctx context.Context, dsn string) (*Connector, error) {
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+
+	db, err := sql.Open("sqlite", dsn+separator+pragmas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err = db.PingContext(timeoutCtx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping SQLite database: %w", err)
+	}
+
+	return &Connector{db: db}, nil
+}
+
+func (c *Connector) DB() *sql.DB {
+	return c.db
+}
+
+func (c *Connector) Close(_ context.Context) error {
+	return c.db.Close()
+}
@@ -0,0 +1,34 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"test-task/wallet/internal/domain/models"
+)
+
+func (s *Storage) GetCurrencies(ctx context.Context) ([]models.CurrencyInfo, error) {
+
+	rows, err := s.db.QueryContext(ctx, "SELECT code, name, scale, enabled FROM currencies")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get currencies: %w", err)
+	}
+	defer rows.Close()
+
+	var currencies []models.CurrencyInfo
+	for rows.Next() {
+		var currency models.CurrencyInfo
+		var code string
+
+		err = rows.Scan(&code, &currency.Name, &currency.Scale, &currency.Enabled)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		currency.Code = models.Currency(code)
+		currencies = append(currencies, currency)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows during get currencies: %w", err)
+	}
+	return currencies, nil
+}
@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"test-task/wallet/internal/domain/models"
+	"time"
+)
+
+func (s *Storage) AddSigningKey(ctx context.Context, key *models.SigningKey) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO signing_keys (id, algorithm, private_key, created_at) VALUES (?, ?, ?, ?)",
+		key.ID, key.Algorithm, key.PrivateKey, formatTime(key.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to add signing key: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) GetSigningKeys(ctx context.Context, createdAfter time.Time) ([]models.SigningKey, error) {
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, algorithm, private_key, created_at FROM signing_keys
+        WHERE created_at > ? ORDER BY created_at, id`, formatTime(createdAfter))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signing keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []models.SigningKey
+	for rows.Next() {
+		var key models.SigningKey
+		var createdAt string
+
+		if err = rows.Scan(&key.ID, &key.Algorithm, &key.PrivateKey, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if key.CreatedAt, err = time.Parse(timeLayout, createdAt); err != nil {
+			return nil, fmt.Errorf("failed to parse signing key time: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows during get signing keys: %w", err)
+	}
+	return keys, nil
+}
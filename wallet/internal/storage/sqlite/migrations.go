@@ -0,0 +1,42 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"log/slog"
+)
+
+func RunMigrations(db *sql.DB, migrationsPath string) error {
+
+	// the driver is not closed: it would close db, which is shared with the storage
+	driver, err := sqlite.WithInstance(db, &sqlite.Config{
+		MigrationsTable: "schema_migrations_wallet",
+	})
+	if err != nil {
+		return fmt.Errorf("could not create sqlite driver: %w", err)
+	}
+
+	m, err := migrate.NewWithDatabaseInstance(
+		migrationsPath,
+		"sqlite",
+		driver,
+	)
+	if err != nil {
+		return fmt.Errorf("could not create migrate instance: %w", err)
+	}
+
+	if err = m.Up(); err != nil {
+		if errors.Is(err, migrate.ErrNoChange) {
+			slog.Info("no new migrations to apply")
+		} else {
+			return fmt.Errorf("could not apply migrations: %w", err)
+		}
+	} else {
+		slog.Info("migrations were applied successfully")
+	}
+	return nil
+}
@@ -0,0 +1,409 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"strings"
+	errs "test-task/wallet/internal/domain/errors"
+	"test-task/wallet/internal/domain/models"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Storage keeps the wallet in SQLite. It has no row locks and no numeric type, so balances are computed
+// in Go and the connector serializes transactions instead.
+type Storage struct {
+	db *sql.DB
+}
+
+func NewStorage(db *sql.DB) *Storage {
+	return &Storage{db: db}
+}
+
+func (s *Storage) AddUser(ctx context.Context, name string, password []byte, email string) error {
+
+	_, err := s.db.ExecContext(ctx, "INSERT INTO users (name, password, email) values (?, ?, ?)",
+		name, password, email)
+
+	if err != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return errs.UserAlreadyExists
+		}
+	}
+
+	return err
+}
+
+func (s *Storage) GetUserByName(ctx context.Context, name string) (*models.User, error) {
+	return scanUser(s.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE name = ?", name))
+}
+
+func (s *Storage) GetUserByNameOrEmail(ctx context.Context, login string) (*models.User, error) {
+	return scanUser(s.db.QueryRowContext(ctx, "SELECT "+userColumns+` FROM users WHERE name = ?1 OR email = ?1
+        ORDER BY name = ?1 DESC LIMIT 1`, login))
+}
+
+func (s *Storage) GetUserByID(ctx context.Context, id int64) (*models.User, error) {
+	return scanUser(s.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?", id))
+}
+
+func (s *Storage) GetUserStatus(ctx context.Context, userID string) (models.UserStatus, error) {
+	return s.getUserStatus(ctx, s.db, userID)
+}
+
+func (s *Storage) getUserStatus(ctx context.Context, executor executor, userID string) (models.UserStatus, error) {
+
+	var status string
+	err := executor.QueryRowContext(ctx, "SELECT status FROM users WHERE id = ?", userID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", errs.UserNotExists
+		}
+		return "", fmt.Errorf("failed to get user status: %w", err)
+	}
+	return models.UserStatus(status), nil
+}
+
+// checkUserActive fails with a dedicated error unless the user may move money. Read in the transaction
+// that moves the money, the status can't change before it ends, as SQLite runs one transaction at a time.
+func (s *Storage) checkUserActive(ctx context.Context, executor executor, userID string) error {
+
+	status, err := s.getUserStatus(ctx, executor, userID)
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case models.UserActive:
+		return nil
+	case models.UserFrozen:
+		return errs.UserFrozen
+	default:
+		return errs.UserClosed
+	}
+}
+
+// checkRecipientActive fails unless the recipient may be credited. A closed account is gone for other users.
+func (s *Storage) checkRecipientActive(ctx context.Context, executor executor, userID string) error {
+
+	status, err := s.getUserStatus(ctx, executor, userID)
+	if err != nil {
+		if errors.Is(err, errs.UserNotExists) {
+			return errs.RecipientNotExists
+		}
+		return err
+	}
+
+	switch status {
+	case models.UserActive:
+		return nil
+	case models.UserFrozen:
+		return errs.RecipientFrozen
+	default:
+		return errs.RecipientNotExists
+	}
+}
+
+const userColumns = "id, name, password, email, roles, status"
+
+// scanUser reads a user selected with userColumns. Roles are stored as a comma separated list.
+func scanUser(row *sql.Row) (*models.User, error) {
+
+	user := models.User{}
+	var roles, status string
+	err := row.Scan(&user.ID, &user.Name, &user.Password, &user.Email, &roles, &status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.UserNotExists
+		}
+		return nil, fmt.Errorf("failed to check user existance in DB: %w", err)
+	}
+	user.Status = models.UserStatus(status)
+
+	for _, role := range strings.Split(roles, ",") {
+		if role != "" {
+			user.Roles = append(user.Roles, models.Role(role))
+		}
+	}
+	return &user, nil
+}
+
+// TransferAccountAmountWithBalance debits fromAmount in the from currency of the sender and credits toAmount
+// in the to currency of the recipient. When the currencies differ, the rate is recorded on both legs and the fee,
+// a part of fromAmount, is moved to the revenue account of the from currency. The debit must fit into the volume
+// limit of withdrawals and, when converted, into that of exchanges as well.
+func (s *Storage) TransferAccountAmountWithBalance(ctx context.Context, fromUserID string, toUserID string,
+	from models.Currency, fromAmount decimal.Decimal, to models.Currency, toAmount decimal.Decimal,
+	rate decimal.Decimal, fee decimal.Decimal, withdrawLimit models.Limit,
+	exchangeLimit models.Limit) (map[models.Currency]decimal.Decimal, error) {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err = s.checkUserActive(ctx, tx, fromUserID); err != nil {
+		return nil, err
+	}
+	if err = s.checkRecipientActive(ctx, tx, toUserID); err != nil {
+		return nil, err
+	}
+
+	fromBalance, err := s.changeAccountAmount(ctx, tx, fromUserID, from, fromAmount.Neg())
+	if err != nil {
+		return nil, err
+	}
+
+	toBalance, err := s.changeAccountAmount(ctx, tx, toUserID, to, toAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	fromLeg := models.Transaction{
+		Type:         models.TransferTransaction,
+		Currency:     from,
+		Amount:       fromAmount.Neg(),
+		BalanceAfter: fromBalance,
+	}
+	toLeg := models.Transaction{
+		Type:         models.TransferTransaction,
+		Currency:     to,
+		Amount:       toAmount,
+		BalanceAfter: toBalance,
+	}
+	if from != to {
+		fromLeg.Rate = &rate
+		fromLeg.Fee = &fee
+		toLeg.Rate = &rate
+	}
+	err = s.addLinkedTransactions(ctx, tx, fromUserID, &fromLeg, toUserID, &toLeg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkVolumeLimit(ctx, tx, fromUserID, models.WithdrawTransaction, from, withdrawLimit)
+	if err != nil {
+		return nil, err
+	}
+	if from != to {
+		err = s.checkVolumeLimit(ctx, tx, fromUserID, models.ExchangeTransaction, from, exchangeLimit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if fee.IsPositive() {
+		if err = s.addRevenue(ctx, tx, from, fee); err != nil {
+			return nil, err
+		}
+	}
+
+	balance, err := s.getBalance(ctx, tx, fromUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return balance, tx.Commit()
+}
+
+// ExchangeAccountAmountWithBalance debits fromAmount and credits toAmount. The fee is a part of fromAmount
+// and is moved to the revenue account of the from currency. The debit must fit into the volume limit.
+func (s *Storage) ExchangeAccountAmountWithBalance(ctx context.Context, userID string, from models.Currency,
+	fromAmount decimal.Decimal, to models.Currency, toAmount decimal.Decimal,
+	rate decimal.Decimal, fee decimal.Decimal, limit models.Limit) (map[models.Currency]decimal.Decimal, error) {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err = s.checkUserActive(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	fromBalance, err := s.changeAccountAmount(ctx, tx, userID, from, fromAmount.Neg())
+	if err != nil {
+		return nil, err
+	}
+
+	toBalance, err := s.changeAccountAmount(ctx, tx, userID, to, toAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	fromLeg := models.Transaction{
+		Type:         models.ExchangeTransaction,
+		Currency:     from,
+		Amount:       fromAmount.Neg(),
+		BalanceAfter: fromBalance,
+		Rate:         &rate,
+		Fee:          &fee,
+	}
+	toLeg := models.Transaction{
+		Type:         models.ExchangeTransaction,
+		Currency:     to,
+		Amount:       toAmount,
+		BalanceAfter: toBalance,
+		Rate:         &rate,
+	}
+	err = s.addLinkedTransactions(ctx, tx, userID, &fromLeg, userID, &toLeg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkVolumeLimit(ctx, tx, userID, models.ExchangeTransaction, from, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if fee.IsPositive() {
+		if err = s.addRevenue(ctx, tx, from, fee); err != nil {
+			return nil, err
+		}
+	}
+
+	balance, err := s.getBalance(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return balance, tx.Commit()
+}
+
+// ChangeAccountAmountWithBalance applies delta to the account. A debit must fit into the volume limit.
+func (s *Storage) ChangeAccountAmountWithBalance(ctx context.Context, userID string, currency models.Currency,
+	delta decimal.Decimal, transactionType models.TransactionType,
+	limit models.Limit) (map[models.Currency]decimal.Decimal, error) {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err = s.checkUserActive(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	newAmount, err := s.changeAccountAmount(ctx, tx, userID, currency, delta)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.addTransaction(ctx, tx, userID, &models.Transaction{
+		Type:         transactionType,
+		Currency:     currency,
+		Amount:       delta,
+		BalanceAfter: newAmount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if delta.IsNegative() {
+		err = s.checkVolumeLimit(ctx, tx, userID, transactionType, currency, limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	balance, err := s.getBalance(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return balance, tx.Commit()
+}
+
+func (s *Storage) GetBalance(ctx context.Context, userID string) (map[models.Currency]decimal.Decimal, error) {
+	return s.getBalance(ctx, s.db, userID)
+}
+
+func (s *Storage) getBalance(ctx context.Context, executor executor, userID string) (map[models.Currency]decimal.Decimal, error) {
+
+	res := make(map[models.Currency]decimal.Decimal)
+
+	rows, err := executor.QueryContext(ctx, "SELECT currency, amount from accounts WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var currency string
+		var amount decimal.Decimal
+
+		err = rows.Scan(&currency, &amount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res[models.Currency(currency)] = amount
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows during get balance: %w", err)
+	}
+	return res, nil
+}
+
+// changeAccountAmount applies delta to the account and returns the new amount. Only a credit may open
+// an account, and errs.InsufficientFunds is returned if the amount would become negative.
+func (s *Storage) changeAccountAmount(ctx context.Context, executor executor, userID string,
+	currency models.Currency, delta decimal.Decimal) (decimal.Decimal, error) {
+
+	var amount decimal.Decimal
+	err := executor.QueryRowContext(ctx, "SELECT amount FROM accounts WHERE user_id = ? AND currency = ?",
+		userID, string(currency)).Scan(&amount)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return decimal.Zero, fmt.Errorf("failed to get amount from DB: %w", err)
+		}
+		if !delta.IsPositive() {
+			return decimal.Zero, errs.InsufficientFunds
+		}
+	}
+
+	newAmount := amount.Add(delta)
+	if newAmount.IsNegative() {
+		return decimal.Zero, errs.InsufficientFunds
+	}
+
+	_, err = executor.ExecContext(ctx, `INSERT INTO accounts (user_id, currency, amount) VALUES (?, ?, ?)
+        ON CONFLICT (user_id, currency) DO UPDATE SET amount = excluded.amount`,
+		userID, string(currency), newAmount)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to change amount in DB: %w", err)
+	}
+	return newAmount, nil
+}
+
+func (s *Storage) addRevenue(ctx context.Context, executor executor, currency models.Currency,
+	amount decimal.Decimal) error {
+
+	var revenue decimal.Decimal
+	err := executor.QueryRowContext(ctx, "SELECT amount FROM revenue_accounts WHERE currency = ?",
+		string(currency)).Scan(&revenue)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get revenue: %w", err)
+	}
+
+	_, err = executor.ExecContext(ctx, `INSERT INTO revenue_accounts (currency, amount) VALUES (?, ?)
+        ON CONFLICT (currency) DO UPDATE SET amount = excluded.amount`, string(currency), revenue.Add(amount))
+	if err != nil {
+		return fmt.Errorf("failed to book fee: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	errs "test-task/wallet/internal/domain/errors"
+	"test-task/wallet/internal/domain/models"
+	"time"
+)
+
+func (s *Storage) AddRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	return s.addRefreshToken(ctx, s.db, token)
+}
+
+func (s *Storage) addRefreshToken(ctx context.Context, executor executor, token *models.RefreshToken) error {
+	_, err := executor.ExecContext(ctx, `INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at)
+        VALUES (?, ?, ?, ?, ?)`, token.UserID, token.FamilyID, token.Hash, formatTime(token.ExpiresAt),
+		formatTime(time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to add refresh token: %w", err)
+	}
+	return nil
+}
+
+// RotateRefreshToken marks the token with the given hash as used and stores next in its family,
+// filling the user and the family of next. If the token was already used, the whole family is revoked
+// and errs.RefreshTokenReused is returned.
+func (s *Storage) RotateRefreshToken(ctx context.Context, hash []byte, next *models.RefreshToken) error {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var expiresAt string
+	var usedAt, revokedAt sql.NullString
+	err = tx.QueryRowContext(ctx, `SELECT user_id, family_id, expires_at, used_at, revoked_at FROM refresh_tokens
+        WHERE token_hash = ?`, hash).Scan(&next.UserID, &next.FamilyID, &expiresAt, &usedAt, &revokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.InvalidRefreshToken
+		}
+		return fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if revokedAt.Valid {
+		return errs.InvalidRefreshToken
+	}
+
+	now := formatTime(time.Now())
+	if usedAt.Valid {
+		_, err = tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL",
+			now, next.FamilyID)
+		if err != nil {
+			return fmt.Errorf("failed to revoke token family: %w", err)
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+		return errs.RefreshTokenReused
+	}
+
+	if now >= expiresAt {
+		return errs.InvalidRefreshToken
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE refresh_tokens SET used_at = ? WHERE token_hash = ?", now, hash)
+	if err != nil {
+		return fmt.Errorf("failed to use refresh token: %w", err)
+	}
+
+	if err = s.addRefreshToken(ctx, tx, next); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeRefreshTokenFamily revokes the family of the user's token with the given hash.
+func (s *Storage) RevokeRefreshTokenFamily(ctx context.Context, userID string, hash []byte) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = ? WHERE revoked_at IS NULL AND family_id =
+        (SELECT family_id FROM refresh_tokens WHERE token_hash = ? AND user_id = ?)`,
+		formatTime(time.Now()), hash, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token family: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) RevokeUserRefreshTokens(ctx context.Context, userID string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL",
+		formatTime(time.Now()), userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"github.com/shopspring/decimal"
+	errs "test-task/wallet/internal/domain/errors"
+	"test-task/wallet/internal/domain/models"
+	"test-task/wallet/internal/tracing"
+	"time"
+)
+
+// timeLayout has a fixed width, so that times stored as TEXT compare in chronological order.
+const timeLayout = "2006-01-02T15:04:05.000000000Z"
+
+const insertTransactionQuery = `INSERT INTO transactions
+    (id, user_id, type, currency, amount, balance_after, counterparty_id, rate, fee, trace_id, created_at)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    RETURNING id`
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func (s *Storage) addTransaction(ctx context.Context, executor executor, userID string,
+	transaction *models.Transaction) (int64, error) {
+
+	var id *int64
+	if transaction.ID != 0 {
+		id = &transaction.ID
+	}
+
+	createdAt := time.Now().UTC()
+	err := executor.QueryRowContext(ctx, insertTransactionQuery, id, userID, string(transaction.Type),
+		string(transaction.Currency), transaction.Amount, transaction.BalanceAfter, transaction.CounterpartyID,
+		transaction.Rate, transaction.Fee, traceID(ctx), formatTime(createdAt)).Scan(&transaction.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add transaction: %w", err)
+	}
+	transaction.CreatedAt = createdAt
+	return transaction.ID, nil
+}
+
+// addLinkedTransactions writes two legs of one operation, each referencing the other as its counterparty.
+// Ids are reserved up front so that both rows can be inserted without updating the append-only table;
+// this is safe because transactions are serialized. The counterparty constraint is checked at commit.
+func (s *Storage) addLinkedTransactions(ctx context.Context, executor executor, firstUserID string,
+	first *models.Transaction, secondUserID string, second *models.Transaction) error {
+
+	var lastID int64
+	err := executor.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM transactions").Scan(&lastID)
+	if err != nil {
+		return fmt.Errorf("failed to reserve transaction ids: %w", err)
+	}
+	first.ID = lastID + 1
+	second.ID = lastID + 2
+
+	first.CounterpartyID = &second.ID
+	second.CounterpartyID = &first.ID
+
+	if _, err = s.addTransaction(ctx, executor, firstUserID, first); err != nil {
+		return err
+	}
+	if _, err = s.addTransaction(ctx, executor, secondUserID, second); err != nil {
+		return err
+	}
+	return nil
+}
+
+func traceID(ctx context.Context) *string {
+	info, err := tracing.GetTraceInfo(ctx)
+	if err != nil {
+		return nil
+	}
+	return &info.TraceID
+}
+
+func (s *Storage) GetTransactions(ctx context.Context, userID string,
+	filter models.TransactionFilter) ([]models.Transaction, error) {
+
+	query := `SELECT id, user_id, type, currency, amount, balance_after, counterparty_id, rate, fee, COALESCE(trace_id, ''), created_at
+    FROM transactions WHERE user_id = ?`
+	args := []any{userID}
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		query += fmt.Sprintf(" AND %s ?", condition)
+	}
+
+	if filter.Currency != "" {
+		addCondition("currency =", string(filter.Currency))
+	}
+	if filter.Type != "" {
+		addCondition("type =", string(filter.Type))
+	}
+	if !filter.From.IsZero() {
+		addCondition("created_at >=", formatTime(filter.From))
+	}
+	if !filter.To.IsZero() {
+		addCondition("created_at <", formatTime(filter.To))
+	}
+	if filter.BeforeID != 0 {
+		addCondition("id <", filter.BeforeID)
+	}
+
+	args = append(args, filter.Limit)
+	query += " ORDER BY id DESC LIMIT ?"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []models.Transaction
+	for rows.Next() {
+		var t models.Transaction
+		var transactionType, currency, createdAt string
+
+		err = rows.Scan(&t.ID, &t.UserID, &transactionType, &currency, &t.Amount, &t.BalanceAfter,
+			&t.CounterpartyID, &t.Rate, &t.Fee, &t.TraceID, &createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if t.CreatedAt, err = time.Parse(timeLayout, createdAt); err != nil {
+			return nil, fmt.Errorf("failed to parse transaction time: %w", err)
+		}
+		t.Type = models.TransactionType(transactionType)
+		t.Currency = models.Currency(currency)
+		transactions = append(transactions, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows during get transactions: %w", err)
+	}
+	return transactions, nil
+}
+
+// volumeConditions select the debits that count towards the limit of a transaction type besides those of
+// the type itself: money sent to another user leaves the wallet like a withdrawal, and is exchanged as well
+// when it is converted on the way.
+var volumeConditions = map[models.TransactionType]string{
+	models.WithdrawTransaction: "(type = ? OR type = 'transfer')",
+	models.ExchangeTransaction: "(type = ? OR (type = 'transfer' AND rate IS NOT NULL))",
+}
+
+func volumeCondition(transactionType models.TransactionType) string {
+	if condition, ok := volumeConditions[transactionType]; ok {
+		return condition
+	}
+	return "type = ?"
+}
+
+// checkVolumeLimit sums up today's and this month's debits that count towards the limit of the given type,
+// including the ones made in the current transaction, and returns errs.LimitExceeded if the limit is exceeded.
+func (s *Storage) checkVolumeLimit(ctx context.Context, executor executor, userID string,
+	transactionType models.TransactionType, currency models.Currency, limit models.Limit) error {
+
+	if !limit.HasVolume() {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	dayStart := formatTime(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+	monthStart := formatTime(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC))
+
+	// amounts are TEXT, so debits are found by their sign and summed up here
+	rows, err := executor.QueryContext(ctx, `SELECT amount, created_at FROM transactions
+    WHERE user_id = ? AND `+volumeCondition(transactionType)+` AND currency = ? AND amount LIKE '-%' AND created_at >= ?`,
+		userID, string(transactionType), string(currency), monthStart)
+	if err != nil {
+		return fmt.Errorf("failed to get debited volume: %w", err)
+	}
+	defer rows.Close()
+
+	daily, monthly := decimal.Zero, decimal.Zero
+	for rows.Next() {
+		var amount decimal.Decimal
+		var createdAt string
+
+		if err = rows.Scan(&amount, &createdAt); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		monthly = monthly.Sub(amount)
+		if createdAt >= dayStart {
+			daily = daily.Sub(amount)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows during get debited volume: %w", err)
+	}
+
+	if !limit.AllowsVolume(daily, monthly) {
+		return errs.LimitExceeded
+	}
+	return nil
+}
@@ -0,0 +1,356 @@
+package http
+
+import (
+	"context"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"log/slog"
+	"net/http"
+	"strconv"
+	errs "test-task/wallet/internal/domain/errors"
+	"test-task/wallet/internal/domain/models"
+	"test-task/wallet/internal/services"
+)
+
+type AdminService interface {
+	FindUser(ctx context.Context, adminID string, login string) (*models.User, error)
+	GetUser(ctx context.Context, adminID string, userID int64) (*models.User, error)
+	GetBalance(ctx context.Context, adminID string, userID int64) (*services.BalanceInfo, error)
+	GetTransactions(ctx context.Context, adminID string, userID int64,
+		filter models.TransactionFilter) (*services.TransactionsPage, error)
+	Freeze(ctx context.Context, adminID string, userID int64, reason string) error
+	Unfreeze(ctx context.Context, adminID string, userID int64, reason string) error
+	Close(ctx context.Context, adminID string, userID int64, reason string) error
+	Adjust(ctx context.Context, adminID string, userID int64, currency models.Currency, amount decimal.Decimal,
+		reason string) (*services.BalanceInfo, error)
+	GetAuditLog(ctx context.Context, adminID string, filter models.AuditFilter) (*services.AuditPage, error)
+}
+
+type AdminHandler struct {
+	service   AdminService
+	validator *validator.Validate
+}
+
+func NewAdminHandler(service AdminService) *AdminHandler {
+	return &AdminHandler{service: service, validator: validator.New()}
+}
+
+// @Summary Find a user
+// @Description Look a user up by name or email
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param login query string true "Name or email"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/users [get]
+func (a *AdminHandler) FindUser(c echo.Context) error {
+	adminID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req FindUserRequest
+	if err = c.Bind(&req); err != nil {
+		slog.Debug("invalid query", "path", c.Path(), "error", err)
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request payload"})
+	}
+	if err = a.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "validation failed: " + err.Error()})
+	}
+
+	user, err := a.service.FindUser(c.Request().Context(), adminID, req.Login)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, userResponse(user))
+}
+
+// @Summary Get a user
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/users/{id} [get]
+func (a *AdminHandler) GetUser(c echo.Context) error {
+	adminID, userID, err := getAdminAndUserID(c)
+	if err != nil {
+		return err
+	}
+
+	user, err := a.service.GetUser(c.Request().Context(), adminID, userID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, userResponse(user))
+}
+
+// @Summary Get the balance of a user
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} BalanceResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/users/{id}/balance [get]
+func (a *AdminHandler) GetBalance(c echo.Context) error {
+	adminID, userID, err := getAdminAndUserID(c)
+	if err != nil {
+		return err
+	}
+
+	balance, err := a.service.GetBalance(c.Request().Context(), adminID, userID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, BalanceResponse{Balance: convertRates(balance.Accounts)})
+}
+
+// @Summary Get the transaction history of a user
+// @Description Get the transactions of a user, newest first, with the same filters as /wallet/transactions
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param currency query string false "Filter by currency"
+// @Param type query string false "Filter by type (deposit, withdraw, exchange, transfer, adjustment)"
+// @Param from query string false "Include transactions created at or after this time (RFC3339)"
+// @Param to query string false "Include transactions created before this time (RFC3339)"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Success 200 {object} TransactionsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/users/{id}/transactions [get]
+func (a *AdminHandler) GetTransactions(c echo.Context) error {
+	adminID, userID, err := getAdminAndUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req GetTransactionsRequest
+	if err = c.Bind(&req); err != nil {
+		slog.Debug("invalid query", "path", c.Path(), "error", err)
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request payload"})
+	}
+
+	filter, invalid := transactionFilter(req)
+	if invalid != "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: invalid})
+	}
+
+	page, err := a.service.GetTransactions(c.Request().Context(), adminID, userID, filter)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, transactionsResponse(page))
+}
+
+// @Summary Freeze a user
+// @Description Stop the user from depositing, withdrawing, exchanging and transferring. The user can still log in
+// @Description and read the wallet
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param reasonRequest body ReasonRequest true "Reason"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/users/{id}/freeze [post]
+func (a *AdminHandler) Freeze(c echo.Context) error {
+	return a.setStatus(c, a.service.Freeze, "User frozen")
+}
+
+// @Summary Unfreeze a user
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param reasonRequest body ReasonRequest true "Reason"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/users/{id}/unfreeze [post]
+func (a *AdminHandler) Unfreeze(c echo.Context) error {
+	return a.setStatus(c, a.service.Unfreeze, "User unfrozen")
+}
+
+// @Summary Close a user
+// @Description Stop the user from logging in and end all of their sessions. Closing can't be undone,
+// @Description but it can be repeated to end the sessions again
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param reasonRequest body ReasonRequest true "Reason"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/users/{id}/close [post]
+func (a *AdminHandler) Close(c echo.Context) error {
+	return a.setStatus(c, a.service.Close, "User closed")
+}
+
+func (a *AdminHandler) setStatus(c echo.Context,
+	set func(ctx context.Context, adminID string, userID int64, reason string) error, message string) error {
+
+	adminID, userID, err := getAdminAndUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req ReasonRequest
+	if err = c.Bind(&req); err != nil {
+		slog.Debug("invalid json request", "path", c.Path(), "error", err)
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request payload"})
+	}
+
+	if err = set(c.Request().Context(), adminID, userID, req.Reason); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, SuccessResponse{Message: message})
+}
+
+// @Summary Adjust the balance of a user
+// @Description Credit a positive or debit a negative amount. The adjustment is recorded in the transaction history
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param adjustmentRequest body AdjustmentRequest true "Adjustment data"
+// @Param Idempotency-Key header string false "Key that makes retries of this request safe"
+// @Success 200 {object} UpdatedBalanceResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /admin/users/{id}/adjustments [post]
+func (a *AdminHandler) Adjust(c echo.Context) error {
+	adminID, userID, err := getAdminAndUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req AdjustmentRequest
+	if err = c.Bind(&req); err != nil {
+		slog.Debug("invalid json request", "path", c.Path(), "error", err)
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request payload"})
+	}
+
+	info, err := a.service.Adjust(c.Request().Context(), adminID, userID, models.Currency(req.Currency),
+		req.Amount, req.Reason)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, UpdatedBalanceResponse{
+		Message:    "Balance adjusted",
+		NewBalance: convertRates(info.Accounts),
+	})
+}
+
+// @Summary Get the audit log
+// @Description Get the actions of admins, newest first
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param admin_id query int false "Filter by admin"
+// @Param user_id query int false "Filter by affected user"
+// @Param limit query int false "Page size (1-100, default 50)"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Success 200 {object} AuditLogResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/audit [get]
+func (a *AdminHandler) GetAuditLog(c echo.Context) error {
+	adminID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req GetAuditLogRequest
+	if err = c.Bind(&req); err != nil {
+		slog.Debug("invalid query", "path", c.Path(), "error", err)
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request payload"})
+	}
+
+	filter := models.AuditFilter{AdminID: req.AdminID, UserID: req.UserID, Limit: req.Limit}
+	if filter.BeforeID, err = decodeCursor(req.Cursor); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid cursor"})
+	}
+
+	page, err := a.service.GetAuditLog(c.Request().Context(), adminID, filter)
+	if err != nil {
+		return err
+	}
+
+	resp := AuditLogResponse{Entries: make([]AuditEntryResponse, 0, len(page.Entries))}
+	for _, e := range page.Entries {
+		resp.Entries = append(resp.Entries, AuditEntryResponse{
+			ID:        e.ID,
+			AdminID:   e.AdminID,
+			Action:    string(e.Action),
+			UserID:    e.UserID,
+			Reason:    e.Reason,
+			Details:   e.Details,
+			TraceID:   e.TraceID,
+			CreatedAt: e.CreatedAt,
+		})
+	}
+	if page.NextCursor != 0 {
+		resp.NextCursor = encodeCursor(page.NextCursor)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// getAdminAndUserID returns the caller and the user of the path.
+func getAdminAndUserID(c echo.Context) (string, int64, error) {
+	adminID, err := getUserID(c)
+	if err != nil {
+		return "", 0, err
+	}
+
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		return "", 0, errs.InvalidUserID
+	}
+	return adminID, userID, nil
+}
+
+func userResponse(user *models.User) UserResponse {
+	resp := UserResponse{ID: user.ID, Name: user.Name, Email: user.Email, Roles: []string{},
+		Status: string(user.Status)}
+	for _, role := range user.Roles {
+		resp.Roles = append(resp.Roles, string(role))
+	}
+	return resp
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"strings"
+	errs "test-task/wallet/internal/domain/errors"
+	"test-task/wallet/internal/domain/models"
+	"test-task/wallet/internal/services"
+	"time"
+)
+
+const principalKey = "principal"
+
+const bearerScheme = "Bearer "
+
+type TokenService interface {
+	ValidateToken(token string) (*services.CustomClaims, error)
+	PublicKeys() []models.PublicKey
+}
+
+type RevocationStore interface {
+	IsTokenRevoked(ctx context.Context, userID, id string, issuedAt time.Time) (bool, error)
+}
+
+// authMiddleware authenticates the request by its bearer token: the token must be valid for this service
+// and not revoked by a logout. The caller is stored in the context, see getPrincipal.
+func authMiddleware(tokens TokenService, revocations RevocationStore) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			if header == "" {
+				return errs.TokenMissing
+			}
+			if len(header) <= len(bearerScheme) || !strings.EqualFold(header[:len(bearerScheme)], bearerScheme) {
+				return fmt.Errorf("%w: expected a bearer token", errs.TokenMalformed)
+			}
+
+			claims, err := tokens.ValidateToken(strings.TrimSpace(header[len(bearerScheme):]))
+			if err != nil {
+				return err
+			}
+
+			principal := &models.Principal{
+				UserID:    claims.Subject,
+				SessionID: claims.SessionID,
+				TokenID:   claims.ID,
+				IssuedAt:  claims.IssuedAt.Time,
+				ExpiresAt: claims.ExpiresAt.Time,
+			}
+			for _, role := range claims.Roles {
+				principal.Roles = append(principal.Roles, models.Role(role))
+			}
+
+			revoked, err := revocations.IsTokenRevoked(c.Request().Context(), principal.UserID, principal.TokenID,
+				principal.IssuedAt)
+			if err != nil {
+				return fmt.Errorf("failed to check token revocation: %w", err)
+			}
+			if revoked {
+				return errs.TokenRevoked
+			}
+
+			c.Set(principalKey, principal)
+			return next(c)
+		}
+	}
+}
+
+// requireRole lets only callers with the role through. It must be used after the auth middleware.
+func requireRole(role models.Role) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+
+			principal, err := getPrincipal(c)
+			if err != nil {
+				return err
+			}
+			if !principal.HasRole(role) {
+				return errs.Forbidden
+			}
+			return next(c)
+		}
+	}
+}
+
+// getPrincipal returns the caller of a route protected by authMiddleware.
+func getPrincipal(c echo.Context) (*models.Principal, error) {
+	principal, ok := c.Get(principalKey).(*models.Principal)
+	if !ok {
+		return nil, errs.TokenMissing
+	}
+	return principal, nil
+}
+
+func getUserID(c echo.Context) (string, error) {
+	principal, err := getPrincipal(c)
+	if err != nil {
+		return "", err
+	}
+	return principal.UserID, nil
+}
@@ -1,5 +1,10 @@
 package http
 
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
 type RegisterRequest struct {
 	Username string `json:"username" validate:"required"`
 	Password string `json:"password" validate:"required"`
@@ -20,40 +25,172 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
 }
 
 type DepositRequest struct {
-	Amount   float64 `json:"amount" validate:"required,gt=0" example:"15"`
-	Currency string  `json:"currency" validate:"required" example:"USD"`
+	Amount   decimal.Decimal `json:"amount" validate:"required" swaggertype:"string" example:"15.50"`
+	Currency string          `json:"currency" validate:"required" example:"USD"`
 }
 
 type WithdrawRequest struct {
-	Amount   float64 `json:"amount" validate:"required,gt=0" example:"15"`
-	Currency string  `json:"currency" validate:"required" example:"USD"`
+	Amount   decimal.Decimal `json:"amount" validate:"required" swaggertype:"string" example:"15.50"`
+	Currency string          `json:"currency" validate:"required" example:"USD"`
+}
+
+type TransferRequest struct {
+	Recipient  string          `json:"recipient" validate:"required" example:"max2"`
+	Amount     decimal.Decimal `json:"amount" validate:"required" swaggertype:"string" example:"15.50"`
+	Currency   string          `json:"currency" validate:"required" example:"USD"`
+	ToCurrency string          `json:"to_currency,omitempty" example:"EUR"`
+}
+
+type TransferResponse struct {
+	Message        string                     `json:"message"`
+	NewBalance     map[string]decimal.Decimal `json:"new_balance" swaggertype:"object,string" example:"USD:20.00,EUR:1.50,RUB:15.00"`
+	CreditedAmount decimal.Decimal            `json:"credited_amount" swaggertype:"string" example:"13.17"`
+	Rate           decimal.Decimal            `json:"rate" swaggertype:"string" example:"0.85"`
+	Fee            decimal.Decimal            `json:"fee" swaggertype:"string" example:"0.08"`
 }
 
 type BalanceResponse struct {
-	Balance map[string]float64 `json:"balance" example:"USD:20.0,EUR:1.5,RUB:15.0"`
+	Balance map[string]decimal.Decimal `json:"balance" swaggertype:"object,string" example:"USD:20.00,EUR:1.50,RUB:15.00"`
 }
 
 type UpdatedBalanceResponse struct {
-	Message    string             `json:"message"`
-	NewBalance map[string]float64 `json:"new_balance" example:"USD:20.0,EUR:1.5,RUB:15.0"`
+	Message    string                     `json:"message"`
+	NewBalance map[string]decimal.Decimal `json:"new_balance" swaggertype:"object,string" example:"USD:20.00,EUR:1.50,RUB:15.00"`
 }
 
 type ExchangeRequest struct {
-	FromCurrency string  `json:"from_currency" validate:"required" example:"USD"`
-	ToCurrency   string  `json:"to_currency" validate:"required" example:"RUB"`
-	Amount       float64 `json:"amount" validate:"required" example:"15"`
+	FromCurrency string          `json:"from_currency" example:"USD"`
+	ToCurrency   string          `json:"to_currency" example:"RUB"`
+	Amount       decimal.Decimal `json:"amount" swaggertype:"string" example:"15.50"`
+	QuoteID      string          `json:"quote_id,omitempty" example:"5b0c1f7e-3f0e-4a8e-9a53-0c8f8a1f6f0e"`
+}
+
+type QuoteRequest struct {
+	FromCurrency string          `json:"from_currency" validate:"required" example:"USD"`
+	ToCurrency   string          `json:"to_currency" validate:"required" example:"RUB"`
+	Amount       decimal.Decimal `json:"amount" validate:"required" swaggertype:"string" example:"15.50"`
+}
+
+type QuoteResponse struct {
+	QuoteID         string          `json:"quote_id" example:"5b0c1f7e-3f0e-4a8e-9a53-0c8f8a1f6f0e"`
+	FromCurrency    string          `json:"from_currency" example:"USD"`
+	ToCurrency      string          `json:"to_currency" example:"RUB"`
+	Amount          decimal.Decimal `json:"amount" swaggertype:"string" example:"15.50"`
+	ExchangedAmount decimal.Decimal `json:"exchanged_amount" swaggertype:"string" example:"155.00"`
+	Fee             decimal.Decimal `json:"fee" swaggertype:"string" example:"0.08"`
+	Rate            decimal.Decimal `json:"rate" swaggertype:"string" example:"10"`
+	ExpiresAt       time.Time       `json:"expires_at" example:"2025-01-01T12:01:00Z"`
 }
 
 type ExchangeResponse struct {
-	Message         string             `json:"message"`
-	NewBalance      map[string]float64 `json:"new_balance" example:"USD:20.0,EUR:1.5,RUB:15.0"`
-	ExchangedAmount float64            `json:"exchanged_amount" example:"15"`
+	Message         string                     `json:"message"`
+	NewBalance      map[string]decimal.Decimal `json:"new_balance" swaggertype:"object,string" example:"USD:20.00,EUR:1.50,RUB:15.00"`
+	ExchangedAmount decimal.Decimal            `json:"exchanged_amount" swaggertype:"string" example:"13.17"`
+	Fee             decimal.Decimal            `json:"fee" swaggertype:"string" example:"0.08"`
 }
 
 type GetRatesResponse struct {
-	Rates map[string]float64 `json:"rates" example:"USD:1.0,EUR:0.85,RUB:0.1"`
+	Rates        map[string]decimal.Decimal `json:"rates" swaggertype:"object,string" example:"USD:1,EUR:0.85,RUB:0.1"`
+	BaseCurrency string                     `json:"base_currency" example:"USD"`
+	Version      int64                      `json:"version" example:"42"`
+	EffectiveAt  time.Time                  `json:"effective_at" example:"2025-01-01T12:00:00Z"`
+}
+
+type GetTransactionsRequest struct {
+	Currency string `query:"currency" example:"USD"`
+	Type     string `query:"type" example:"deposit"`
+	From     string `query:"from" example:"2025-01-01T00:00:00Z"`
+	To       string `query:"to" example:"2025-02-01T00:00:00Z"`
+	Limit    int    `query:"limit" example:"20"`
+	Cursor   string `query:"cursor"`
+}
+
+type TransactionResponse struct {
+	ID             int64            `json:"id" example:"42"`
+	Type           string           `json:"type" example:"exchange"`
+	Currency       string           `json:"currency" example:"USD"`
+	Amount         decimal.Decimal  `json:"amount" swaggertype:"string" example:"-15.00"`
+	BalanceAfter   decimal.Decimal  `json:"balance_after" swaggertype:"string" example:"85.00"`
+	CounterpartyID *int64           `json:"counterparty_id,omitempty" example:"43"`
+	Rate           *decimal.Decimal `json:"rate,omitempty" swaggertype:"string" example:"0.85"`
+	Fee            *decimal.Decimal `json:"fee,omitempty" swaggertype:"string" example:"0.08"`
+	CreatedAt      time.Time        `json:"created_at" example:"2025-01-01T12:00:00Z"`
+}
+
+type TransactionsResponse struct {
+	Transactions []TransactionResponse `json:"transactions"`
+	NextCursor   string                `json:"next_cursor,omitempty"`
+}
+
+type UserResponse struct {
+	ID     int64    `json:"id" example:"7"`
+	Name   string   `json:"name" example:"max"`
+	Email  string   `json:"email" example:"max@example.com"`
+	Roles  []string `json:"roles" example:"user"`
+	Status string   `json:"status" example:"active"`
+}
+
+type FindUserRequest struct {
+	Login string `query:"login" validate:"required" example:"max"`
+}
+
+type ReasonRequest struct {
+	Reason string `json:"reason" validate:"required" example:"Chargeback investigation #123"`
+}
+
+type AdjustmentRequest struct {
+	Amount   decimal.Decimal `json:"amount" validate:"required" swaggertype:"string" example:"-15.50"`
+	Currency string          `json:"currency" validate:"required" example:"USD"`
+	Reason   string          `json:"reason" validate:"required" example:"Refund of a duplicate withdrawal"`
+}
+
+type GetAuditLogRequest struct {
+	AdminID int64  `query:"admin_id" example:"1"`
+	UserID  int64  `query:"user_id" example:"7"`
+	Limit   int    `query:"limit" example:"50"`
+	Cursor  string `query:"cursor"`
+}
+
+type AuditEntryResponse struct {
+	ID        int64             `json:"id" example:"42"`
+	AdminID   *int64            `json:"admin_id,omitempty" example:"1"`
+	Action    string            `json:"action" example:"adjust"`
+	UserID    *int64            `json:"user_id,omitempty" example:"7"`
+	Reason    string            `json:"reason,omitempty" example:"Refund of a duplicate withdrawal"`
+	Details   map[string]string `json:"details,omitempty"`
+	TraceID   string            `json:"trace_id,omitempty"`
+	CreatedAt time.Time         `json:"created_at" example:"2025-01-01T12:00:00Z"`
+}
+
+type AuditLogResponse struct {
+	Entries    []AuditEntryResponse `json:"entries"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+type JWKSResponse struct {
+	Keys []JWK `json:"keys"`
+}
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
 }
@@ -2,19 +2,25 @@ package http
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"github.com/go-playground/validator/v10"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"test-task/wallet/internal/domain/models"
 	"test-task/wallet/internal/services"
+	"time"
 )
 
 type AuthService interface {
 	Register(ctx context.Context, username, password, email string) error
-	Login(ctx context.Context, username, password string) (string, error)
+	Login(ctx context.Context, username, password string) (*models.Tokens, error)
+	Refresh(ctx context.Context, refreshToken string) (*models.Tokens, error)
+	Logout(ctx context.Context, userID, tokenID string, expiresAt time.Time, refreshToken string) error
+	LogoutAll(ctx context.Context, userID string) error
 }
 
 type AuthHandler struct {
@@ -63,6 +69,8 @@ func (a *AuthHandler) Register(c echo.Context) error {
 // @Param loginRequest body LoginRequest true "Login credentials"
 // @Success 200 {object} LoginResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Router /login [post]
 func (a *AuthHandler) Login(c echo.Context) error {
 	var req LoginRequest
@@ -77,19 +85,107 @@ func (a *AuthHandler) Login(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "validation failed: " + err.Error()})
 	}
 
-	token, err := a.service.Login(c.Request().Context(), req.Username, req.Password)
+	tokens, err := a.service.Login(c.Request().Context(), req.Username, req.Password)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, LoginResponse{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken})
+}
+
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access token and a new refresh token.
+// @Description A refresh token can be used only once: using it again revokes every token issued from the same login
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param refreshRequest body RefreshRequest true "Refresh token"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /token/refresh [post]
+func (a *AuthHandler) Refresh(c echo.Context) error {
+	var req RefreshRequest
+	if err := c.Bind(&req); err != nil {
+		slog.Debug("invalid json request", "path", c.Path(), "error", err)
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request payload"})
+	}
+
+	err := a.validator.Struct(req)
+	if err != nil {
+		slog.Debug("invalid json request", "path", c.Path(), "error", err)
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "validation failed: " + err.Error()})
+	}
+
+	tokens, err := a.service.Refresh(c.Request().Context(), req.RefreshToken)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, LoginResponse{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken})
+}
+
+// @Summary Log out
+// @Description Revoke the access token. Pass the refresh token to end the session, otherwise it can still be refreshed
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param logoutRequest body LogoutRequest false "Refresh token of the session"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /logout [post]
+func (a *AuthHandler) Logout(c echo.Context) error {
+	principal, err := getPrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	var req LogoutRequest
+	if err = c.Bind(&req); err != nil {
+		slog.Debug("invalid json request", "path", c.Path(), "error", err)
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request payload"})
+	}
+
+	err = a.service.Logout(c.Request().Context(), principal.UserID, principal.TokenID, principal.ExpiresAt,
+		req.RefreshToken)
 	if err != nil {
 		return err
 	}
-	return c.JSON(http.StatusOK, LoginResponse{Token: token})
+	return c.JSON(http.StatusOK, SuccessResponse{Message: "Logged out"})
+}
+
+// @Summary Log out of all sessions
+// @Description Revoke every access token and every refresh token of the user
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /logout/all [post]
+func (a *AuthHandler) LogoutAll(c echo.Context) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err = a.service.LogoutAll(c.Request().Context(), userID); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, SuccessResponse{Message: "Logged out of all sessions"})
 }
 
 type WalletService interface {
-	GetExchangeRates(ctx context.Context) (map[models.Currency]float64, error)
+	GetExchangeRates(ctx context.Context) (*models.ExchangeRates, error)
 	GetBalance(ctx context.Context, userID string) (*services.BalanceInfo, error)
-	Withdraw(ctx context.Context, userID string, currency models.Currency, amount float64) (*services.BalanceInfo, error)
-	Deposit(ctx context.Context, userID string, currency models.Currency, amount float64) (*services.BalanceInfo, error)
-	Exchange(ctx context.Context, userID string, from models.Currency, to models.Currency, amount float64) (*services.ExchangeInfo, error)
+	Withdraw(ctx context.Context, userID string, currency models.Currency, amount decimal.Decimal) (*services.BalanceInfo, error)
+	Deposit(ctx context.Context, userID string, currency models.Currency, amount decimal.Decimal) (*services.BalanceInfo, error)
+	Exchange(ctx context.Context, userID string, from models.Currency, to models.Currency, amount decimal.Decimal) (*services.ExchangeInfo, error)
+	CreateQuote(ctx context.Context, userID string, from models.Currency, to models.Currency, amount decimal.Decimal) (*models.Quote, error)
+	ExchangeWithQuote(ctx context.Context, userID string, quoteID string) (*services.ExchangeInfo, error)
+	Transfer(ctx context.Context, userID string, recipient string, from models.Currency, to models.Currency,
+		amount decimal.Decimal) (*services.TransferInfo, error)
+	GetTransactions(ctx context.Context, userID string, filter models.TransactionFilter) (*services.TransactionsPage, error)
 }
 
 type WalletHandler struct {
@@ -110,7 +206,7 @@ func NewWalletHandler(service WalletService) *WalletHandler {
 // @Failure 401 {object} ErrorResponse
 // @Router /balance [get]
 func (w *WalletHandler) GetBalance(c echo.Context) error {
-	userID, err := getUserIdFromToken(c)
+	userID, err := getUserID(c)
 	if err != nil {
 		return err
 	}
@@ -131,12 +227,16 @@ func (w *WalletHandler) GetBalance(c echo.Context) error {
 // @Produce json
 // @Security BearerAuth
 // @Param depositRequest body DepositRequest true "Deposit data"
+// @Param Idempotency-Key header string false "Key that makes retries of this request safe"
 // @Success 200 {object} UpdatedBalanceResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
 // @Router /wallet/deposit [post]
 func (w *WalletHandler) Deposit(c echo.Context) error {
-	userID, err := getUserIdFromToken(c)
+	userID, err := getUserID(c)
 	if err != nil {
 		return err
 	}
@@ -165,12 +265,17 @@ func (w *WalletHandler) Deposit(c echo.Context) error {
 // @Produce json
 // @Security BearerAuth
 // @Param withdrawRequest body WithdrawRequest true "Withdraw data"
+// @Param Idempotency-Key header string false "Key that makes retries of this request safe"
 // @Success 200 {object} UpdatedBalanceResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
 // @Router /wallet/withdraw [post]
 func (w *WalletHandler) Withdraw(c echo.Context) error {
-	userID, err := getUserIdFromToken(c)
+	userID, err := getUserID(c)
 	if err != nil {
 		return err
 	}
@@ -192,6 +297,57 @@ func (w *WalletHandler) Withdraw(c echo.Context) error {
 	})
 }
 
+// @Summary Transfer money to another user
+// @Description Transfer a specified amount of money to another user identified by username or email.
+// @Description If to_currency is set, the recipient is credited in that currency at the current exchange rate,
+// @Description after the same fee an exchange is charged. Frozen users can neither send nor receive transfers.
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param transferRequest body TransferRequest true "Transfer data"
+// @Param Idempotency-Key header string false "Key that makes retries of this request safe"
+// @Success 200 {object} TransferResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /wallet/transfer [post]
+func (w *WalletHandler) Transfer(c echo.Context) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req TransferRequest
+	if err = c.Bind(&req); err != nil {
+		slog.Debug("invalid json request", "path", c.Path(), "error", err)
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request payload"})
+	}
+
+	toCurrency := req.ToCurrency
+	if toCurrency == "" {
+		toCurrency = req.Currency
+	}
+
+	info, err := w.service.Transfer(c.Request().Context(), userID, req.Recipient, models.Currency(req.Currency),
+		models.Currency(toCurrency), req.Amount)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, TransferResponse{
+		Message:        "Transfer successful",
+		NewBalance:     convertRates(info.Accounts),
+		CreditedAmount: info.CreditedAmount,
+		Rate:           info.Rate,
+		Fee:            info.Fee,
+	})
+}
+
 // @Summary Get exchange rates
 // @Description Retrieve exchange rates for different currencies
 // @Tags wallet
@@ -206,28 +362,36 @@ func (w *WalletHandler) GetRates(c echo.Context) error {
 		return err
 	}
 
-	res := make(map[string]float64)
-	for k, v := range rates {
-		res[string(k)] = v
-	}
-
-	return c.JSON(http.StatusOK, GetRatesResponse{Rates: res})
+	return c.JSON(http.StatusOK, GetRatesResponse{
+		Rates:        convertRates(rates.Rates),
+		BaseCurrency: string(rates.Base),
+		Version:      rates.Version,
+		EffectiveAt:  rates.EffectiveAt,
+	})
 }
 
 // @Summary Exchange one currency for another
-// @Description Exchange one currency for another in the user's wallet
+// @Description Exchange one currency for another in the user's wallet.
+// @Description If quote_id is set, the exchange is executed at exactly the quoted rate and amounts,
+// @Description and from_currency, to_currency and amount are ignored.
+// @Description The fee is taken from the amount in from_currency before the conversion.
 // @Tags wallet
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param exchangeRequest body ExchangeRequest true "Exchange data"
+// @Param Idempotency-Key header string false "Key that makes retries of this request safe"
 // @Success 200 {object} ExchangeResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
 // @Router /exchange [post]
 func (w *WalletHandler) Exchange(c echo.Context) error {
 
-	userID, err := getUserIdFromToken(c)
+	userID, err := getUserID(c)
 	if err != nil {
 		return err
 	}
@@ -238,8 +402,13 @@ func (w *WalletHandler) Exchange(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request payload"})
 	}
 
-	info, err := w.service.Exchange(c.Request().Context(), userID, models.Currency(req.FromCurrency),
-		models.Currency(req.ToCurrency), req.Amount)
+	var info *services.ExchangeInfo
+	if req.QuoteID != "" {
+		info, err = w.service.ExchangeWithQuote(c.Request().Context(), userID, req.QuoteID)
+	} else {
+		info, err = w.service.Exchange(c.Request().Context(), userID, models.Currency(req.FromCurrency),
+			models.Currency(req.ToCurrency), req.Amount)
+	}
 	if err != nil {
 		return err
 	}
@@ -248,35 +417,169 @@ func (w *WalletHandler) Exchange(c echo.Context) error {
 		Message:         "Exchange successful",
 		NewBalance:      convertRates(info.Accounts),
 		ExchangedAmount: info.ExchangedAmount,
+		Fee:             info.Fee,
 	})
 }
 
-func getUserIdFromToken(c echo.Context) (string, error) {
-	token, ok := c.Get("user").(*jwt.Token)
-	if !ok {
-		return "", c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Token is missing or invalid"})
+// @Summary Get the transaction history of a user
+// @Description Get deposits, withdrawals and exchanges of a user, newest first, with cursor pagination
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param currency query string false "Filter by currency"
+// @Param type query string false "Filter by type (deposit, withdraw, exchange, transfer, adjustment)"
+// @Param from query string false "Include transactions created at or after this time (RFC3339)"
+// @Param to query string false "Include transactions created before this time (RFC3339)"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Success 200 {object} TransactionsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /wallet/transactions [get]
+func (w *WalletHandler) GetTransactions(c echo.Context) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return "", fmt.Errorf("failed to cast claims as jwt.MapClaims")
+
+	var req GetTransactionsRequest
+	if err = c.Bind(&req); err != nil {
+		slog.Debug("invalid query", "path", c.Path(), "error", err)
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request payload"})
 	}
 
-	extra, ok := claims["extra"].(map[string]any)
-	if !ok {
-		return "", fmt.Errorf("failed to cast extra")
+	filter, invalid := transactionFilter(req)
+	if invalid != "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: invalid})
 	}
 
-	id, ok := extra["id"].(string)
-	if !ok {
-		return "", fmt.Errorf("id field is missing or not a string")
+	page, err := w.service.GetTransactions(c.Request().Context(), userID, filter)
+	if err != nil {
+		return err
 	}
-	return id, nil
+
+	return c.JSON(http.StatusOK, transactionsResponse(page))
+}
+
+// transactionFilter converts the query of a transaction history request. If it is invalid,
+// the message for the client is returned instead.
+func transactionFilter(req GetTransactionsRequest) (models.TransactionFilter, string) {
+
+	filter := models.TransactionFilter{
+		Currency: models.Currency(req.Currency),
+		Type:     models.TransactionType(req.Type),
+		Limit:    req.Limit,
+	}
+
+	var err error
+	if filter.From, err = parseTime(req.From); err != nil {
+		return filter, "invalid 'from' time"
+	}
+	if filter.To, err = parseTime(req.To); err != nil {
+		return filter, "invalid 'to' time"
+	}
+	if filter.BeforeID, err = decodeCursor(req.Cursor); err != nil {
+		return filter, "invalid cursor"
+	}
+	return filter, ""
+}
+
+func transactionsResponse(page *services.TransactionsPage) TransactionsResponse {
+
+	resp := TransactionsResponse{Transactions: make([]TransactionResponse, 0, len(page.Transactions))}
+	for _, t := range page.Transactions {
+		resp.Transactions = append(resp.Transactions, TransactionResponse{
+			ID:             t.ID,
+			Type:           string(t.Type),
+			Currency:       string(t.Currency),
+			Amount:         t.Amount,
+			BalanceAfter:   t.BalanceAfter,
+			CounterpartyID: t.CounterpartyID,
+			Rate:           t.Rate,
+			Fee:            t.Fee,
+			CreatedAt:      t.CreatedAt,
+		})
+	}
+	if page.NextCursor != 0 {
+		resp.NextCursor = encodeCursor(page.NextCursor)
+	}
+	return resp
 }
 
-func convertRates(rates map[models.Currency]float64) map[string]float64 {
-	formattedRates := make(map[string]float64)
+// @Summary Get an exchange quote
+// @Description Lock the current exchange rate for a limited time. The returned quote_id can be passed to /exchange once.
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param quoteRequest body QuoteRequest true "Quote data"
+// @Success 200 {object} QuoteResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /exchange/quote [post]
+func (w *WalletHandler) CreateQuote(c echo.Context) error {
+
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req QuoteRequest
+	if err = c.Bind(&req); err != nil {
+		slog.Debug("invalid json request", "path", c.Path(), "error", err)
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request payload"})
+	}
+
+	quote, err := w.service.CreateQuote(c.Request().Context(), userID, models.Currency(req.FromCurrency),
+		models.Currency(req.ToCurrency), req.Amount)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, QuoteResponse{
+		QuoteID:         quote.ID,
+		FromCurrency:    string(quote.From),
+		ToCurrency:      string(quote.To),
+		Amount:          quote.Amount,
+		ExchangedAmount: quote.ExchangedAmount,
+		Fee:             quote.Fee,
+		Rate:            quote.Rate,
+		ExpiresAt:       quote.ExpiresAt,
+	})
+}
+
+func convertRates(rates map[models.Currency]decimal.Decimal) map[string]decimal.Decimal {
+	formattedRates := make(map[string]decimal.Decimal)
 	for k, v := range rates {
 		formattedRates[string(k)] = v
 	}
 	return formattedRates
 }
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseInt(string(decoded), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return id, nil
+}
@@ -0,0 +1,133 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/labstack/echo/v4"
+	"io"
+	"log/slog"
+	"net/http"
+	errs "test-task/wallet/internal/domain/errors"
+	"test-task/wallet/internal/domain/models"
+	"time"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(ctx context.Context, userID, key, fingerprint string,
+		lifetime time.Duration) (*models.IdempotencyRecord, error)
+	CompleteIdempotencyKey(ctx context.Context, userID, key string, record models.IdempotencyRecord) error
+	ReleaseIdempotencyKey(ctx context.Context, userID, key, fingerprint string) error
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// idempotencyMiddleware makes a handler safe to retry: a request repeated with the same Idempotency-Key
+// gets the original response instead of being executed again. Keys are scoped per user and must be used
+// after the auth middleware.
+func idempotencyMiddleware(store IdempotencyStore, lifetime time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+
+			key := c.Request().Header.Get(idempotencyKeyHeader)
+			if key == "" {
+				return next(c)
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				return errs.InvalidIdempotencyKey
+			}
+
+			userID, err := getUserID(c)
+			if err != nil {
+				return err
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return err
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := c.Request().Context()
+			// the real path, not the route, so that one key can't be reused for another user of a parameterised route
+			fingerprint := requestFingerprint(c.Request().Method, c.Request().URL.Path, body)
+
+			existing, err := store.ReserveIdempotencyKey(ctx, userID, key, fingerprint, lifetime)
+			if err != nil {
+				return err
+			}
+
+			if existing != nil {
+				if existing.Fingerprint != fingerprint {
+					return errs.IdempotencyKeyReused
+				}
+				if !existing.IsCompleted() {
+					return errs.IdempotencyKeyInProgress
+				}
+				slog.Debug("replaying idempotent response", "path", c.Path(), "key", key)
+				c.Response().Header().Set(idempotentReplayedHeader, "true")
+				return c.JSONBlob(existing.StatusCode, existing.Body)
+			}
+
+			// the key is settled even when the client has gone away or the handler panics, otherwise it would
+			// stay pending for its whole lifetime and every retry would get a conflict
+			settleCtx := context.WithoutCancel(ctx)
+			release := func() {
+				if err := store.ReleaseIdempotencyKey(settleCtx, userID, key, fingerprint); err != nil {
+					slog.Error("failed to release idempotency key", "key", key, "error", err)
+				}
+			}
+			defer func() {
+				if r := recover(); r != nil {
+					release()
+					panic(r)
+				}
+			}()
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			if err = next(c); err != nil {
+				c.Error(err)
+			}
+
+			if c.Response().Status >= http.StatusInternalServerError {
+				// the outcome is unknown, let the client retry with the same key
+				release()
+				return nil
+			}
+
+			err = store.CompleteIdempotencyKey(settleCtx, userID, key, models.IdempotencyRecord{
+				Fingerprint: fingerprint,
+				StatusCode:  c.Response().Status,
+				Body:        recorder.body.Bytes(),
+			})
+			if err != nil {
+				slog.Error("failed to save idempotent response", "key", key, "error", err)
+			}
+			return nil
+		}
+	}
+}
+
+func requestFingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
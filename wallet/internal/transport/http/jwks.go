@@ -0,0 +1,51 @@
+package http
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"github.com/labstack/echo/v4"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"test-task/wallet/internal/domain/models"
+)
+
+// jwksHandler publishes the public keys in the JSON Web Key Set format (RFC 7517), so that other services
+// can verify wallet tokens. It is served outside of the API, at the well-known path.
+func jwksHandler(keys TokenService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+
+		res := JWKSResponse{Keys: []JWK{}}
+		for _, key := range keys.PublicKeys() {
+			jwk, ok := toJWK(key)
+			if !ok {
+				slog.Warn("skipping public key of unsupported type", "kid", key.ID, "algorithm", key.Algorithm)
+				continue
+			}
+			res.Keys = append(res.Keys, jwk)
+		}
+
+		c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=60")
+		return c.JSON(http.StatusOK, res)
+	}
+}
+
+func toJWK(key models.PublicKey) (JWK, bool) {
+
+	jwk := JWK{KeyID: key.ID, Algorithm: key.Algorithm, Use: "sig"}
+
+	switch public := key.Key.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	default:
+		return JWK{}, false
+	}
+	return jwk, true
+}
@@ -2,7 +2,6 @@ package http
 
 import (
 	"github.com/go-playground/validator/v10"
-	"github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/pkg/errors"
@@ -11,13 +10,16 @@ import (
 	"log/slog"
 	"net/http"
 	errs "test-task/wallet/internal/domain/errors"
+	"test-task/wallet/internal/domain/models"
 	"test-task/wallet/internal/tracing"
+	"time"
 )
 
 type Config struct {
-	ServiceName   string
-	JwtSecret     string
-	LaunchSwagger bool
+	ServiceName            string
+	Tokens                 TokenService
+	LaunchSwagger          bool
+	IdempotencyKeyLifetime time.Duration
 }
 
 type customValidator struct {
@@ -40,13 +42,13 @@ func (cv *customValidator) Validate(i interface{}) error {
 // @in header
 // @name Authorization
 
-func NewServer(config Config, walletService WalletService, authService AuthService) *echo.Echo {
+func NewServer(config Config, walletService WalletService, authService AuthService, adminService AdminService,
+	idempotencyStore IdempotencyStore, revocationStore RevocationStore) *echo.Echo {
 
 	e := echo.New()
-	jwtMiddleware := echojwt.WithConfig(echojwt.Config{
-		SigningKey:    []byte(config.JwtSecret),
-		SigningMethod: "HS512",
-	})
+	jwtMiddleware := authMiddleware(config.Tokens, revocationStore)
+
+	idempotency := idempotencyMiddleware(idempotencyStore, config.IdempotencyKeyLifetime)
 
 	v := validator.New()
 	e.Validator = &customValidator{validator: v}
@@ -59,17 +61,38 @@ func NewServer(config Config, walletService WalletService, authService AuthServi
 
 	auth := NewAuthHandler(authService)
 	wallet := NewWalletHandler(walletService)
+	admin := NewAdminHandler(adminService)
+
+	e.GET("/.well-known/jwks.json", jwksHandler(config.Tokens))
 
 	api := e.Group("/api/v1")
 
 	api.POST("/register", auth.Register)
 	api.POST("/login", auth.Login)
+	api.POST("/token/refresh", auth.Refresh)
+	api.POST("/logout", auth.Logout, jwtMiddleware)
+	api.POST("/logout/all", auth.LogoutAll, jwtMiddleware)
 
 	api.GET("/exchange/rates", wallet.GetRates)
-	api.POST("/exchange", wallet.Exchange, jwtMiddleware)
-	api.POST("/wallet/withdraw", wallet.Withdraw, jwtMiddleware)
-	api.POST("/wallet/deposit", wallet.Deposit, jwtMiddleware)
+	api.POST("/exchange", wallet.Exchange, jwtMiddleware, idempotency)
+	api.POST("/exchange/quote", wallet.CreateQuote, jwtMiddleware)
+	api.POST("/wallet/withdraw", wallet.Withdraw, jwtMiddleware, idempotency)
+	api.POST("/wallet/deposit", wallet.Deposit, jwtMiddleware, idempotency)
+	api.POST("/wallet/transfer", wallet.Transfer, jwtMiddleware, idempotency)
 	api.GET("/balance", wallet.GetBalance, jwtMiddleware)
+	api.GET("/wallet/transactions", wallet.GetTransactions, jwtMiddleware)
+
+	adminAPI := api.Group("/admin", jwtMiddleware, requireRole(models.RoleAdmin))
+
+	adminAPI.GET("/users", admin.FindUser)
+	adminAPI.GET("/users/:id", admin.GetUser)
+	adminAPI.GET("/users/:id/balance", admin.GetBalance)
+	adminAPI.GET("/users/:id/transactions", admin.GetTransactions)
+	adminAPI.POST("/users/:id/freeze", admin.Freeze)
+	adminAPI.POST("/users/:id/unfreeze", admin.Unfreeze)
+	adminAPI.POST("/users/:id/close", admin.Close)
+	adminAPI.POST("/users/:id/adjustments", admin.Adjust, idempotency)
+	adminAPI.GET("/audit", admin.GetAuditLog)
 
 	if config.LaunchSwagger {
 		e.GET("/swagger/*", echoSwagger.WrapHandler)
@@ -102,19 +125,96 @@ func errorHandler(err error, c echo.Context) {
 	case errors.Is(err, errs.UserNotExists) || errors.Is(err, errs.WrongPassword):
 		code = http.StatusUnauthorized
 		message = "Invalid username or password"
+	case errors.Is(err, errs.UserFrozen):
+		code = http.StatusForbidden
+		message = "Account is frozen"
+	case errors.Is(err, errs.UserClosed):
+		code = http.StatusForbidden
+		message = "Account is closed"
+	case errors.Is(err, errs.InvalidRefreshToken):
+		code = http.StatusUnauthorized
+		message = "Invalid refresh token"
+	case errors.Is(err, errs.RefreshTokenReused):
+		code = http.StatusUnauthorized
+		message = "Refresh token reused, please log in again"
+	case errors.Is(err, errs.TokenMissing):
+		code = http.StatusUnauthorized
+		message = "Missing token"
+	case errors.Is(err, errs.TokenMalformed):
+		code = http.StatusUnauthorized
+		message = "Malformed token"
+		slog.Debug("malformed token", "path", c.Path(), "error", err)
+	case errors.Is(err, errs.TokenInvalidSignature):
+		code = http.StatusUnauthorized
+		message = "Invalid token signature"
+		slog.Debug("invalid token signature", "path", c.Path(), "error", err)
+	case errors.Is(err, errs.TokenExpired):
+		code = http.StatusUnauthorized
+		message = "Token expired"
+	case errors.Is(err, errs.TokenNotValidYet):
+		code = http.StatusUnauthorized
+		message = "Token not valid yet"
+	case errors.Is(err, errs.TokenInvalidIssuer):
+		code = http.StatusUnauthorized
+		message = "Invalid token issuer"
+	case errors.Is(err, errs.TokenInvalidAudience):
+		code = http.StatusUnauthorized
+		message = "Invalid token audience"
+	case errors.Is(err, errs.TokenInvalidClaims):
+		code = http.StatusUnauthorized
+		message = "Invalid token claims"
+		slog.Debug("invalid token claims", "path", c.Path(), "error", err)
+	case errors.Is(err, errs.TokenRevoked):
+		code = http.StatusUnauthorized
+		message = "Token revoked"
 	case errors.Is(err, errs.InvalidAmount) || errors.Is(err, errs.InvalidCurrency):
 		code = http.StatusBadRequest
 		message = "Invalid amount or currency"
+	case errors.Is(err, errs.InvalidFilter):
+		code = http.StatusBadRequest
+		message = "Invalid filter"
+	case errors.Is(err, errs.RecipientNotExists):
+		code = http.StatusNotFound
+		message = "Recipient not found"
+	case errors.Is(err, errs.RecipientFrozen):
+		code = http.StatusForbidden
+		message = "Recipient can't receive transfers"
+	case errors.Is(err, errs.InvalidRecipient):
+		code = http.StatusBadRequest
+		message = "Invalid recipient"
+	case errors.Is(err, errs.QuoteNotExists):
+		code = http.StatusNotFound
+		message = "Quote not found"
+	case errors.Is(err, errs.QuoteExpired):
+		code = http.StatusBadRequest
+		message = "Quote expired"
+	case errors.Is(err, errs.InvalidIdempotencyKey):
+		code = http.StatusBadRequest
+		message = "Invalid idempotency key"
+	case errors.Is(err, errs.IdempotencyKeyReused):
+		code = http.StatusUnprocessableEntity
+		message = "Idempotency key was already used for a different request"
+	case errors.Is(err, errs.IdempotencyKeyInProgress):
+		code = http.StatusConflict
+		message = "Request with this idempotency key is still in progress"
+	case errors.Is(err, errs.LimitExceeded):
+		code = http.StatusTooManyRequests
+		message = "Limit exceeded"
 	case errors.Is(err, errs.InsufficientFunds):
 		code = http.StatusBadRequest
 		message = "Insufficient funds"
-	case errors.Is(err, echojwt.ErrJWTInvalid):
-		code = http.StatusUnauthorized
-		message = "Invalid JWT"
-		slog.Debug("invalid jwt", "path", c.Path(), "error", err)
-	case errors.Is(err, echojwt.ErrJWTMissing):
-		code = http.StatusUnauthorized
-		message = "Missing JWT"
+	case errors.Is(err, errs.Forbidden):
+		code = http.StatusForbidden
+		message = "Forbidden"
+	case errors.Is(err, errs.UserNotFound):
+		code = http.StatusNotFound
+		message = "User not found"
+	case errors.Is(err, errs.InvalidUserID):
+		code = http.StatusBadRequest
+		message = "Invalid user id"
+	case errors.Is(err, errs.ReasonRequired):
+		code = http.StatusBadRequest
+		message = "Reason is required"
 	case errors.Is(err, echo.ErrNotFound):
 		code = http.StatusNotFound
 		message = "Not Found"
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS transactions_append_only ON transactions;
+DROP FUNCTION IF EXISTS transactions_append_only();
+DROP TABLE IF EXISTS transactions;
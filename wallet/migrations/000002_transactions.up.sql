@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS transactions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL references users(id),
+    type TEXT NOT NULL CHECK ( type IN ('deposit', 'withdraw', 'exchange') ),
+    currency TEXT NOT NULL references currencies(code),
+    amount DECIMAL NOT NULL CHECK ( amount <> 0 ),
+    balance_after DECIMAL NOT NULL CHECK ( balance_after >= 0 ),
+    counterparty_id BIGINT references transactions(id) DEFERRABLE INITIALLY IMMEDIATE,
+    trace_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS transactions_user_created_idx ON transactions (user_id, created_at, id);
+
+CREATE OR REPLACE FUNCTION transactions_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'transactions table is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER transactions_append_only
+    BEFORE UPDATE OR DELETE ON transactions
+    FOR EACH ROW EXECUTE FUNCTION transactions_append_only();
@@ -0,0 +1 @@
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS rate DECIMAL CHECK ( rate > 0 );
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS revenue_accounts (
+    currency TEXT PRIMARY KEY references currencies(code),
+    amount DECIMAL NOT NULL CHECK ( amount >= 0 )
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fee DECIMAL CHECK ( fee >= 0 );
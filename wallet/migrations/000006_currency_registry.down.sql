@@ -0,0 +1,3 @@
+ALTER TABLE currencies DROP COLUMN IF EXISTS enabled;
+ALTER TABLE currencies DROP COLUMN IF EXISTS scale;
+ALTER TABLE currencies DROP COLUMN IF EXISTS name;
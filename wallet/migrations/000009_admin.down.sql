@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+    CHECK ( type IN ('deposit', 'withdraw', 'exchange', 'transfer') );
+
+ALTER TABLE users DROP COLUMN IF EXISTS frozen;
+ALTER TABLE users DROP COLUMN IF EXISTS roles;
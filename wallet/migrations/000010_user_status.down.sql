@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS frozen BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- closed users can't log in, as frozen ones couldn't
+UPDATE users SET frozen = TRUE WHERE status <> 'active';
+
+ALTER TABLE users DROP COLUMN IF EXISTS status;
@@ -0,0 +1,2 @@
+-- encrypted keys can't be used by the previous version, which creates new ones on start
+DELETE FROM signing_keys;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS revenue_accounts;
+DROP TRIGGER IF EXISTS transactions_append_only_delete;
+DROP TRIGGER IF EXISTS transactions_append_only_update;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS accounts;
+DROP TABLE IF EXISTS currencies;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,56 @@
+-- amounts are stored as TEXT, so that decimals keep their exact value; balances are checked by the storage
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT UNIQUE NOT NULL,
+    password BLOB NOT NULL,
+    email TEXT UNIQUE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS currencies (
+    code TEXT PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    scale INTEGER NOT NULL DEFAULT 2 CHECK ( scale BETWEEN 0 AND 18 ),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+INSERT INTO currencies (code, name) VALUES ('USD', 'US Dollar'), ('EUR', 'Euro'), ('RUB', 'Russian Ruble')
+    ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS accounts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    currency TEXT NOT NULL REFERENCES currencies(code),
+    amount TEXT NOT NULL,
+    CONSTRAINT unique_user_currency UNIQUE (user_id, currency)
+);
+
+CREATE TABLE IF NOT EXISTS transactions (
+    id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    type TEXT NOT NULL CHECK ( type IN ('deposit', 'withdraw', 'exchange', 'transfer') ),
+    currency TEXT NOT NULL REFERENCES currencies(code),
+    amount TEXT NOT NULL,
+    balance_after TEXT NOT NULL,
+    counterparty_id INTEGER REFERENCES transactions(id) DEFERRABLE INITIALLY DEFERRED,
+    rate TEXT,
+    fee TEXT,
+    trace_id TEXT,
+    created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS transactions_user_created_idx ON transactions (user_id, created_at, id);
+
+CREATE TRIGGER IF NOT EXISTS transactions_append_only_update BEFORE UPDATE ON transactions
+BEGIN
+    SELECT RAISE(ABORT, 'transactions table is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS transactions_append_only_delete BEFORE DELETE ON transactions
+BEGIN
+    SELECT RAISE(ABORT, 'transactions table is append-only');
+END;
+
+CREATE TABLE IF NOT EXISTS revenue_accounts (
+    currency TEXT PRIMARY KEY REFERENCES currencies(code),
+    amount TEXT NOT NULL
+);
@@ -0,0 +1,35 @@
+DROP TABLE IF EXISTS audit_log;
+
+ALTER TABLE transactions RENAME TO transactions_new;
+
+CREATE TABLE transactions (
+    id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    type TEXT NOT NULL CHECK ( type IN ('deposit', 'withdraw', 'exchange', 'transfer') ),
+    currency TEXT NOT NULL REFERENCES currencies(code),
+    amount TEXT NOT NULL,
+    balance_after TEXT NOT NULL,
+    counterparty_id INTEGER REFERENCES transactions(id) DEFERRABLE INITIALLY DEFERRED,
+    rate TEXT,
+    fee TEXT,
+    trace_id TEXT,
+    created_at TEXT NOT NULL
+);
+
+INSERT INTO transactions SELECT * FROM transactions_new;
+DROP TABLE transactions_new;
+
+CREATE INDEX IF NOT EXISTS transactions_user_created_idx ON transactions (user_id, created_at, id);
+
+CREATE TRIGGER IF NOT EXISTS transactions_append_only_update BEFORE UPDATE ON transactions
+BEGIN
+    SELECT RAISE(ABORT, 'transactions table is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS transactions_append_only_delete BEFORE DELETE ON transactions
+BEGIN
+    SELECT RAISE(ABORT, 'transactions table is append-only');
+END;
+
+ALTER TABLE users DROP COLUMN frozen;
+ALTER TABLE users DROP COLUMN roles;
@@ -0,0 +1,61 @@
+-- roles are a comma separated list
+ALTER TABLE users ADD COLUMN roles TEXT NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD COLUMN frozen BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- a CHECK constraint can't be altered, so transactions are moved to a new table; renaming the old one first
+-- keeps the counterparty references of the copied rows pointing to the new table
+ALTER TABLE transactions RENAME TO transactions_old;
+
+CREATE TABLE transactions (
+    id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    type TEXT NOT NULL CHECK ( type IN ('deposit', 'withdraw', 'exchange', 'transfer', 'adjustment') ),
+    currency TEXT NOT NULL REFERENCES currencies(code),
+    amount TEXT NOT NULL,
+    balance_after TEXT NOT NULL,
+    counterparty_id INTEGER REFERENCES transactions(id) DEFERRABLE INITIALLY DEFERRED,
+    rate TEXT,
+    fee TEXT,
+    trace_id TEXT,
+    created_at TEXT NOT NULL
+);
+
+INSERT INTO transactions SELECT * FROM transactions_old;
+DROP TABLE transactions_old;
+
+CREATE INDEX IF NOT EXISTS transactions_user_created_idx ON transactions (user_id, created_at, id);
+
+CREATE TRIGGER IF NOT EXISTS transactions_append_only_update BEFORE UPDATE ON transactions
+BEGIN
+    SELECT RAISE(ABORT, 'transactions table is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS transactions_append_only_delete BEFORE DELETE ON transactions
+BEGIN
+    SELECT RAISE(ABORT, 'transactions table is append-only');
+END;
+
+-- admin_id is NULL for actions taken by the service itself
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    admin_id INTEGER REFERENCES users(id),
+    action TEXT NOT NULL,
+    user_id INTEGER REFERENCES users(id),
+    reason TEXT NOT NULL DEFAULT '',
+    details TEXT NOT NULL DEFAULT '{}',
+    trace_id TEXT,
+    created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_user_idx ON audit_log (user_id, id);
+CREATE INDEX IF NOT EXISTS audit_log_admin_idx ON audit_log (admin_id, id);
+
+CREATE TRIGGER IF NOT EXISTS audit_log_append_only_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log table is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_append_only_delete BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log table is append-only');
+END;
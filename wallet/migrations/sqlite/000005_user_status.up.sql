@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN status TEXT NOT NULL DEFAULT 'active'
+    CHECK ( status IN ('active', 'frozen', 'closed') );
+
+UPDATE users SET status = 'frozen' WHERE frozen;
+
+ALTER TABLE users DROP COLUMN frozen;
@@ -0,0 +1,3 @@
+-- private keys are stored encrypted with the key encryption key from now on, the plaintext ones are dropped
+-- and new keys are created on start. Access tokens signed with them fail, clients refresh them
+DELETE FROM signing_keys;
@@ -0,0 +1,357 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"strconv"
+	"test-task/wallet/internal/domain/models"
+	myhttp "test-task/wallet/internal/transport/http"
+	"testing"
+)
+
+// loginAdmin registers a user, grants it the admin role and logs it in again, so the token carries the role.
+func loginAdmin(t *testing.T) (myhttp.RegisterRequest, string) {
+
+	registerReq, _ := registerAndLogin(t)
+	require.NoError(t, adminService.GrantAdmins(context.Background(), []string{registerReq.Username}))
+
+	resp := mustSend[myhttp.LoginResponse](t, server, "POST", apiPrefix+"login",
+		myhttp.LoginRequest{Username: registerReq.Username, Password: registerReq.Password}, http.StatusOK, nil)
+	return registerReq, resp.Token
+}
+
+func findUser(t *testing.T, adminToken, login string) *myhttp.UserResponse {
+	return mustSend[myhttp.UserResponse](t, server, "GET",
+		apiPrefix+"admin/users?login="+url.QueryEscape(login), nil, http.StatusOK, withToken(adminToken))
+}
+
+func userPath(id int64, suffix string) string {
+	return apiPrefix + "admin/users/" + strconv.FormatInt(id, 10) + suffix
+}
+
+func TestAdmin_RequiresAdminRole(t *testing.T) {
+
+	registerReq, token := getUserWithToken(t)
+
+	resp := mustSend[myhttp.ErrorResponse](t, server, "GET",
+		apiPrefix+"admin/users?login="+registerReq.Username, nil, http.StatusForbidden, withToken(token))
+	assert.Equal(t, "Forbidden", resp.Error)
+
+	// the role is read from the token, so it takes effect on the next login
+	require.NoError(t, adminService.GrantAdmins(context.Background(), []string{registerReq.Username}))
+	_ = mustSend[myhttp.ErrorResponse](t, server, "GET", apiPrefix+"admin/audit", nil, http.StatusForbidden,
+		withToken(token))
+
+	_ = mustSend[myhttp.ErrorResponse](t, server, "GET", apiPrefix+"admin/audit", nil, http.StatusUnauthorized, nil)
+}
+
+func TestAdmin_FindAndGetUser(t *testing.T) {
+
+	_, adminToken := loginAdmin(t)
+	user, _ := getUserWithToken(t)
+
+	byName := findUser(t, adminToken, user.Username)
+	assert.Equal(t, user.Username, byName.Name)
+	assert.Equal(t, user.Email, byName.Email)
+	assert.Equal(t, []string{"user"}, byName.Roles)
+	assert.Equal(t, "active", byName.Status)
+
+	byEmail := findUser(t, adminToken, user.Email)
+	assert.Equal(t, byName.ID, byEmail.ID)
+
+	byID := mustSend[myhttp.UserResponse](t, server, "GET", userPath(byName.ID, ""), nil, http.StatusOK,
+		withToken(adminToken))
+	assert.Equal(t, *byName, *byID)
+
+	resp := mustSend[myhttp.ErrorResponse](t, server, "GET", apiPrefix+"admin/users?login=nobody", nil,
+		http.StatusNotFound, withToken(adminToken))
+	assert.Equal(t, "User not found", resp.Error)
+
+	resp = mustSend[myhttp.ErrorResponse](t, server, "GET", userPath(1<<40, ""), nil, http.StatusNotFound,
+		withToken(adminToken))
+	assert.Equal(t, "User not found", resp.Error)
+
+	resp = mustSend[myhttp.ErrorResponse](t, server, "GET", apiPrefix+"admin/users/abc", nil,
+		http.StatusBadRequest, withToken(adminToken))
+	assert.Equal(t, "Invalid user id", resp.Error)
+}
+
+func TestAdmin_GetBalanceAndTransactions(t *testing.T) {
+
+	_, adminToken := loginAdmin(t)
+	user, token := getUserWithToken(t)
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST", apiPrefix+"wallet/deposit",
+		myhttp.DepositRequest{Amount: decimal.RequireFromString("40"), Currency: "EUR"}, http.StatusOK,
+		withToken(token))
+
+	id := findUser(t, adminToken, user.Username).ID
+
+	balance := mustSend[myhttp.BalanceResponse](t, server, "GET", userPath(id, "/balance"), nil, http.StatusOK,
+		withToken(adminToken))
+	assertDecimalEqual(t, decimal.RequireFromString("40"), balance.Balance["EUR"])
+
+	history := mustSend[myhttp.TransactionsResponse](t, server, "GET", userPath(id, "/transactions?currency=EUR"),
+		nil, http.StatusOK, withToken(adminToken))
+	require.Len(t, history.Transactions, 1)
+	assert.Equal(t, "deposit", history.Transactions[0].Type)
+	assertDecimalEqual(t, decimal.RequireFromString("40"), history.Transactions[0].Amount)
+}
+
+func TestAdmin_Freeze(t *testing.T) {
+
+	_, adminToken := loginAdmin(t)
+	user, tokens := registerAndLogin(t)
+	recipient, _ := getUserWithToken(t)
+	usd := myhttp.DepositRequest{Amount: decimal.RequireFromString("10"), Currency: "USD"}
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST", apiPrefix+"wallet/deposit", usd, http.StatusOK,
+		withToken(tokens.Token))
+	quote := mustSend[myhttp.QuoteResponse](t, server, "POST", apiPrefix+"exchange/quote",
+		myhttp.QuoteRequest{FromCurrency: "USD", ToCurrency: "EUR", Amount: decimal.RequireFromString("1")},
+		http.StatusOK, withToken(tokens.Token))
+
+	id := findUser(t, adminToken, user.Username).ID
+
+	resp := mustSend[myhttp.ErrorResponse](t, server, "POST", userPath(id, "/freeze"), myhttp.ReasonRequest{},
+		http.StatusBadRequest, withToken(adminToken))
+	assert.Equal(t, "Reason is required", resp.Error)
+
+	_ = mustSend[myhttp.SuccessResponse](t, server, "POST", userPath(id, "/freeze"),
+		myhttp.ReasonRequest{Reason: "Suspected account takeover"}, http.StatusOK, withToken(adminToken))
+
+	assert.Equal(t, "frozen", findUser(t, adminToken, user.Username).Status)
+
+	// the user can still log in and read the wallet
+	relogin := mustSend[myhttp.LoginResponse](t, server, "POST", apiPrefix+"login",
+		myhttp.LoginRequest{Username: user.Username, Password: user.Password}, http.StatusOK, nil)
+	for _, token := range []string{tokens.Token, relogin.Token} {
+		balance := mustSend[myhttp.BalanceResponse](t, server, "GET", apiPrefix+"balance", nil, http.StatusOK,
+			withToken(token))
+		assertDecimalEqual(t, decimal.RequireFromString("10"), balance.Balance["USD"])
+	}
+	_ = mustSend[myhttp.TransactionsResponse](t, server, "GET", apiPrefix+"wallet/transactions", nil, http.StatusOK,
+		withToken(tokens.Token))
+
+	// but can't move money
+	moves := []struct {
+		path    string
+		request any
+	}{
+		{"wallet/deposit", usd},
+		{"wallet/withdraw", myhttp.WithdrawRequest{Amount: decimal.RequireFromString("1"), Currency: "USD"}},
+		{"exchange", myhttp.ExchangeRequest{FromCurrency: "USD", ToCurrency: "EUR",
+			Amount: decimal.RequireFromString("1")}},
+		{"exchange", myhttp.ExchangeRequest{QuoteID: quote.QuoteID}},
+		{"exchange/quote", myhttp.QuoteRequest{FromCurrency: "USD", ToCurrency: "EUR",
+			Amount: decimal.RequireFromString("1")}},
+		{"wallet/transfer", myhttp.TransferRequest{Recipient: recipient.Username,
+			Amount: decimal.RequireFromString("1"), Currency: "USD"}},
+	}
+	for _, move := range moves {
+		resp = mustSend[myhttp.ErrorResponse](t, server, "POST", apiPrefix+move.path, move.request,
+			http.StatusForbidden, withToken(tokens.Token))
+		assert.Equal(t, "Account is frozen", resp.Error, move.path)
+	}
+
+	// nor receive it
+	_, senderToken := getUserWithToken(t)
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST", apiPrefix+"wallet/deposit", usd, http.StatusOK,
+		withToken(senderToken))
+	resp = mustSend[myhttp.ErrorResponse](t, server, "POST", apiPrefix+"wallet/transfer",
+		myhttp.TransferRequest{Recipient: user.Username, Amount: decimal.RequireFromString("1"), Currency: "USD"},
+		http.StatusForbidden, withToken(senderToken))
+	assert.Equal(t, "Recipient can't receive transfers", resp.Error)
+
+	_ = mustSend[myhttp.SuccessResponse](t, server, "POST", userPath(id, "/unfreeze"),
+		myhttp.ReasonRequest{Reason: "Owner verified"}, http.StatusOK, withToken(adminToken))
+
+	// the quote was not used up by the refused exchange
+	_ = mustSend[myhttp.ExchangeResponse](t, server, "POST", apiPrefix+"exchange",
+		myhttp.ExchangeRequest{QuoteID: quote.QuoteID}, http.StatusOK, withToken(tokens.Token))
+	balance := mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST", apiPrefix+"wallet/deposit", usd,
+		http.StatusOK, withToken(tokens.Token))
+	assertDecimalEqual(t, decimal.RequireFromString("19"), balance.NewBalance["USD"])
+}
+
+func TestAdmin_Close(t *testing.T) {
+
+	_, adminToken := loginAdmin(t)
+	user, tokens := registerAndLogin(t)
+	_, senderToken := getUserWithToken(t)
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST", apiPrefix+"wallet/deposit",
+		myhttp.DepositRequest{Amount: decimal.RequireFromString("10"), Currency: "USD"}, http.StatusOK,
+		withToken(senderToken))
+
+	id := findUser(t, adminToken, user.Username).ID
+
+	_ = mustSend[myhttp.SuccessResponse](t, server, "POST", userPath(id, "/close"),
+		myhttp.ReasonRequest{Reason: "Confirmed fraud"}, http.StatusOK, withToken(adminToken))
+
+	assert.Equal(t, "closed", findUser(t, adminToken, user.Username).Status)
+
+	// the sessions of the user end
+	_ = mustSend[myhttp.ErrorResponse](t, server, "GET", apiPrefix+"balance", nil, http.StatusUnauthorized,
+		withToken(tokens.Token))
+	resp := mustSend[myhttp.ErrorResponse](t, server, "POST", apiPrefix+"token/refresh",
+		myhttp.RefreshRequest{RefreshToken: tokens.RefreshToken}, http.StatusUnauthorized, nil)
+	assert.Equal(t, "Invalid refresh token", resp.Error)
+
+	resp = mustSend[myhttp.ErrorResponse](t, server, "POST", apiPrefix+"login",
+		myhttp.LoginRequest{Username: user.Username, Password: user.Password}, http.StatusForbidden, nil)
+	assert.Equal(t, "Account is closed", resp.Error)
+
+	resp = mustSend[myhttp.ErrorResponse](t, server, "POST", apiPrefix+"wallet/transfer",
+		myhttp.TransferRequest{Recipient: user.Username, Amount: decimal.RequireFromString("1"), Currency: "USD"},
+		http.StatusNotFound, withToken(senderToken))
+	assert.Equal(t, "Recipient not found", resp.Error)
+
+	// closing is final
+	resp = mustSend[myhttp.ErrorResponse](t, server, "POST", userPath(id, "/unfreeze"),
+		myhttp.ReasonRequest{Reason: "Reopen"}, http.StatusForbidden, withToken(adminToken))
+	assert.Equal(t, "Account is closed", resp.Error)
+
+	// but it can be repeated, so that a close whose logout failed can be retried
+	_ = mustSend[myhttp.SuccessResponse](t, server, "POST", userPath(id, "/close"),
+		myhttp.ReasonRequest{Reason: "Confirmed fraud"}, http.StatusOK, withToken(adminToken))
+}
+
+func TestAdmin_CloseRetriedAfterFailedLogout(t *testing.T) {
+
+	_, adminToken := loginAdmin(t)
+	user, tokens := registerAndLogin(t)
+
+	id := findUser(t, adminToken, user.Username).ID
+	revocations.failUser(strconv.FormatInt(id, 10))
+	t.Cleanup(func() { revocations.failUser("") })
+
+	_ = mustSend[myhttp.ErrorResponse](t, server, "POST", userPath(id, "/close"),
+		myhttp.ReasonRequest{Reason: "Confirmed fraud"}, http.StatusInternalServerError, withToken(adminToken))
+	assert.Equal(t, "closed", findUser(t, adminToken, user.Username).Status)
+
+	// the session outlived the failed close, the retry ends it
+	_ = mustSend[myhttp.BalanceResponse](t, server, "GET", apiPrefix+"balance", nil, http.StatusOK,
+		withToken(tokens.Token))
+
+	revocations.failUser("")
+	_ = mustSend[myhttp.SuccessResponse](t, server, "POST", userPath(id, "/close"),
+		myhttp.ReasonRequest{Reason: "Confirmed fraud"}, http.StatusOK, withToken(adminToken))
+
+	_ = mustSend[myhttp.ErrorResponse](t, server, "GET", apiPrefix+"balance", nil, http.StatusUnauthorized,
+		withToken(tokens.Token))
+}
+
+func TestAdmin_Adjust(t *testing.T) {
+
+	_, adminToken := loginAdmin(t)
+	user, token := getUserWithToken(t)
+
+	id := findUser(t, adminToken, user.Username).ID
+
+	resp := mustSend[myhttp.ErrorResponse](t, server, "POST", userPath(id, "/adjustments"),
+		myhttp.AdjustmentRequest{Amount: decimal.RequireFromString("10"), Currency: "USD"},
+		http.StatusBadRequest, withToken(adminToken))
+	assert.Equal(t, "Reason is required", resp.Error)
+
+	credit := mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST", userPath(id, "/adjustments"),
+		myhttp.AdjustmentRequest{Amount: decimal.RequireFromString("25"), Currency: "USD", Reason: "Goodwill credit"},
+		http.StatusOK, withToken(adminToken))
+	assertDecimalEqual(t, decimal.RequireFromString("25"), credit.NewBalance["USD"])
+
+	debit := mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST", userPath(id, "/adjustments"),
+		myhttp.AdjustmentRequest{Amount: decimal.RequireFromString("-5"), Currency: "USD", Reason: "Duplicate credit"},
+		http.StatusOK, withToken(adminToken))
+	assertDecimalEqual(t, decimal.RequireFromString("20"), debit.NewBalance["USD"])
+
+	resp = mustSend[myhttp.ErrorResponse](t, server, "POST", userPath(id, "/adjustments"),
+		myhttp.AdjustmentRequest{Amount: decimal.RequireFromString("-21"), Currency: "USD", Reason: "Overdraw"},
+		http.StatusBadRequest, withToken(adminToken))
+	assert.Equal(t, "Insufficient funds", resp.Error)
+
+	balance := mustSend[myhttp.BalanceResponse](t, server, "GET", apiPrefix+"balance", nil, http.StatusOK,
+		withToken(token))
+	assertDecimalEqual(t, decimal.RequireFromString("20"), balance.Balance["USD"])
+
+	history := mustSend[myhttp.TransactionsResponse](t, server, "GET", apiPrefix+"wallet/transactions?type=adjustment",
+		nil, http.StatusOK, withToken(token))
+	require.Len(t, history.Transactions, 2)
+	assertDecimalEqual(t, decimal.RequireFromString("-5"), history.Transactions[0].Amount)
+	assertDecimalEqual(t, decimal.RequireFromString("20"), history.Transactions[0].BalanceAfter)
+	assertDecimalEqual(t, decimal.RequireFromString("25"), history.Transactions[1].Amount)
+}
+
+func TestAdmin_AdjustIdempotencyKeyPerUser(t *testing.T) {
+
+	_, adminToken := loginAdmin(t)
+	first, _ := getUserWithToken(t)
+	second, secondToken := getUserWithToken(t)
+
+	// admin keys are scoped to the admin, so the key must tell one user of the route from another
+	key := "adjust-" + first.Username
+	withKey := func(request *http.Request) {
+		withToken(adminToken)(request)
+		request.Header.Set("Idempotency-Key", key)
+	}
+	req := myhttp.AdjustmentRequest{Amount: decimal.RequireFromString("10"), Currency: "USD", Reason: "Goodwill credit"}
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST",
+		userPath(findUser(t, adminToken, first.Username).ID, "/adjustments"), req, http.StatusOK, withKey)
+
+	resp := mustSend[myhttp.ErrorResponse](t, server, "POST",
+		userPath(findUser(t, adminToken, second.Username).ID, "/adjustments"), req, http.StatusUnprocessableEntity, withKey)
+	assert.Equal(t, "Idempotency key was already used for a different request", resp.Error)
+
+	balance := mustSend[myhttp.BalanceResponse](t, server, "GET", apiPrefix+"balance", nil, http.StatusOK,
+		withToken(secondToken))
+	assert.True(t, balance.Balance["USD"].IsZero(), "the second user must not be adjusted")
+}
+
+func TestAdmin_AuditLog(t *testing.T) {
+
+	admin, adminToken := loginAdmin(t)
+	user, _ := getUserWithToken(t)
+
+	adminID := findUser(t, adminToken, admin.Username).ID
+	id := findUser(t, adminToken, user.Username).ID
+
+	_ = mustSend[myhttp.BalanceResponse](t, server, "GET", userPath(id, "/balance"), nil, http.StatusOK,
+		withToken(adminToken))
+	adjusted := mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST", userPath(id, "/adjustments"),
+		myhttp.AdjustmentRequest{Amount: decimal.RequireFromString("3"), Currency: "USD", Reason: "Refund"},
+		http.StatusOK, withToken(adminToken))
+	assertDecimalEqual(t, decimal.RequireFromString("3"), adjusted.NewBalance["USD"])
+
+	log := mustSend[myhttp.AuditLogResponse](t, server, "GET",
+		fmt.Sprintf("%sadmin/audit?user_id=%d", apiPrefix, id), nil, http.StatusOK, withToken(adminToken))
+	require.Len(t, log.Entries, 3)
+
+	adjust, view, find := log.Entries[0], log.Entries[1], log.Entries[2]
+	assert.Equal(t, string(models.AuditAdjust), adjust.Action)
+	assert.Equal(t, "Refund", adjust.Reason)
+	assert.Equal(t, "3", adjust.Details["amount"])
+	assert.Equal(t, "USD", adjust.Details["currency"])
+	assert.NotEmpty(t, adjust.Details["transaction_id"])
+	assert.Equal(t, string(models.AuditViewBalance), view.Action)
+	assert.Equal(t, string(models.AuditFindUser), find.Action)
+	assert.Equal(t, user.Username, find.Details["login"])
+	for _, e := range log.Entries {
+		require.NotNil(t, e.AdminID)
+		assert.Equal(t, adminID, *e.AdminID)
+		require.NotNil(t, e.UserID)
+		assert.Equal(t, id, *e.UserID)
+	}
+
+	// reads of the audit log are audited too
+	page := mustSend[myhttp.AuditLogResponse](t, server, "GET",
+		fmt.Sprintf("%sadmin/audit?admin_id=%d&limit=1", apiPrefix, adminID), nil, http.StatusOK,
+		withToken(adminToken))
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, string(models.AuditViewAuditLog), page.Entries[0].Action)
+	assert.NotEmpty(t, page.NextCursor)
+}
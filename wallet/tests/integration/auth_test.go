@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"test-task/wallet/internal/services"
+	myhttp "test-task/wallet/internal/transport/http"
+	"testing"
+	"time"
+)
+
+// issueToken signs a token with the keys of the servers, but with the config changed by modify.
+func issueToken(t *testing.T, modify func(cfg *services.JWTConfig), subject services.TokenSubject) string {
+
+	cfg := jwtConfig
+	modify(&cfg)
+
+	jwt, err := services.NewJwtService(cfg, keyRepository)
+	require.NoError(t, err)
+	require.NoError(t, jwt.RotateKeys(context.Background()))
+
+	token, err := jwt.CreateToken(subject, nil)
+	require.NoError(t, err)
+	return token
+}
+
+func TestAuth_Rejects(t *testing.T) {
+
+	subject := services.TokenSubject{UserID: "1"}
+
+	tests := []struct {
+		name    string
+		header  string
+		message string
+	}{
+		{"missing token", "", "Missing token"},
+		{"other scheme", "Basic dXNlcjpwYXNz", "Malformed token"},
+		{"malformed token", "Bearer not-a-token", "Malformed token"},
+		{"foreign signature", "Bearer " + foreignToken(t), "Invalid token signature"},
+		{"wrong audience", "Bearer " + issueToken(t, func(cfg *services.JWTConfig) {
+			cfg.Audience = "AnotherAudience"
+		}, subject), "Invalid token audience"},
+		{"wrong issuer", "Bearer " + issueToken(t, func(cfg *services.JWTConfig) {
+			cfg.Issuer = "AnotherIssuer"
+		}, subject), "Invalid token issuer"},
+		{"no subject", "Bearer " + issueToken(t, func(cfg *services.JWTConfig) {}, services.TokenSubject{}),
+			"Invalid token claims"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := mustSend[myhttp.ErrorResponse](t, server, "GET", apiPrefix+"balance", nil,
+				http.StatusUnauthorized, func(req *http.Request) {
+					if tt.header != "" {
+						req.Header.Set("Authorization", tt.header)
+					}
+				})
+			assert.Equal(t, tt.message, resp.Error)
+		})
+	}
+}
+
+func TestAuth_RejectsExpiredToken(t *testing.T) {
+
+	token := issueToken(t, func(cfg *services.JWTConfig) {
+		cfg.Lifetime = time.Second
+	}, services.TokenSubject{UserID: "1"})
+
+	time.Sleep(2 * time.Second)
+
+	resp := mustSend[myhttp.ErrorResponse](t, server, "GET", apiPrefix+"balance", nil, http.StatusUnauthorized,
+		withToken(token))
+	assert.Equal(t, "Token expired", resp.Error)
+}
+
+// foreignToken is signed by a key the servers don't know.
+func foreignToken(t *testing.T) string {
+
+	jwt, err := services.NewJwtService(jwtConfig, newKeyRepositoryMock())
+	require.NoError(t, err)
+	require.NoError(t, jwt.RotateKeys(context.Background()))
+
+	token, err := jwt.CreateToken(services.TokenSubject{UserID: "1"}, nil)
+	require.NoError(t, err)
+	return token
+}
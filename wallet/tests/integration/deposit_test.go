@@ -1,6 +1,7 @@
 package integration
 
 import (
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"sync"
@@ -12,7 +13,7 @@ func TestDeposit_Success(t *testing.T) {
 
 	token := getToken(t)
 	req := myhttp.DepositRequest{
-		Amount:   100.5,
+		Amount:   decimal.RequireFromString("100.5"),
 		Currency: "USD",
 	}
 
@@ -21,7 +22,7 @@ func TestDeposit_Success(t *testing.T) {
 			request.Header.Set("Authorization", "Bearer "+token)
 		})
 
-	assert.Equal(t, req.Amount, resp.NewBalance[req.Currency])
+	assertDecimalEqual(t, req.Amount, resp.NewBalance[req.Currency])
 	assert.Equal(t, "Account topped up successfully", resp.Message)
 
 	resp = mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST",
@@ -29,13 +30,13 @@ func TestDeposit_Success(t *testing.T) {
 			request.Header.Set("Authorization", "Bearer "+token)
 		})
 
-	assert.Equal(t, req.Amount*2, resp.NewBalance[req.Currency])
+	assertDecimalEqual(t, req.Amount.Mul(decimal.NewFromInt(2)), resp.NewBalance[req.Currency])
 }
 
 func TestDeposit_MissingToken(t *testing.T) {
 
 	req := myhttp.DepositRequest{
-		Amount:   100.5,
+		Amount:   decimal.RequireFromString("100.5"),
 		Currency: "USD",
 	}
 
@@ -47,7 +48,7 @@ func TestDeposit_NegativeAmount(t *testing.T) {
 
 	token := getToken(t)
 	req := myhttp.DepositRequest{
-		Amount:   -100.5,
+		Amount:   decimal.RequireFromString("-100.5"),
 		Currency: "USD",
 	}
 
@@ -63,7 +64,7 @@ func TestDeposit_InvalidCurrency(t *testing.T) {
 
 	token := getToken(t)
 	req := myhttp.DepositRequest{
-		Amount:   100.5,
+		Amount:   decimal.RequireFromString("100.5"),
 		Currency: "tugrik",
 	}
 
@@ -79,13 +80,13 @@ func TestDeposit_Concurrently(t *testing.T) {
 
 	token := getToken(t)
 	req := myhttp.DepositRequest{
-		Amount:   100,
+		Amount:   decimal.RequireFromString("100"),
 		Currency: "USD",
 	}
 	requests := 100
-	expectedSum := req.Amount * float64(requests)
+	expectedSum := req.Amount.Mul(decimal.NewFromInt(int64(requests)))
 
-	var returnedBalances []float64
+	var returnedBalances []decimal.Decimal
 	var mu sync.Mutex
 
 	wg := sync.WaitGroup{}
@@ -106,7 +107,7 @@ func TestDeposit_Concurrently(t *testing.T) {
 	wg.Wait()
 
 	for i := 1; i < requests; i++ {
-		assert.Equal(t, returnedBalances[i-1]+req.Amount, returnedBalances[i])
+		assertDecimalEqual(t, returnedBalances[i-1].Add(req.Amount), returnedBalances[i])
 	}
 
 	balance := mustSend[myhttp.BalanceResponse](t, server, "GET",
@@ -114,5 +115,5 @@ func TestDeposit_Concurrently(t *testing.T) {
 			request.Header.Set("Authorization", "Bearer "+token)
 		})
 
-	assert.Equal(t, expectedSum, balance.Balance[req.Currency])
+	assertDecimalEqual(t, expectedSum, balance.Balance[req.Currency])
 }
@@ -1,6 +1,7 @@
 package integration
 
 import (
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"test-task/wallet/internal/domain/models"
@@ -13,7 +14,7 @@ func TestExchange_Success(t *testing.T) {
 	token := getToken(t)
 
 	req1 := myhttp.DepositRequest{
-		Amount:   100,
+		Amount:   decimal.RequireFromString("100"),
 		Currency: "USD",
 	}
 
@@ -23,7 +24,7 @@ func TestExchange_Success(t *testing.T) {
 		})
 
 	req2 := myhttp.DepositRequest{
-		Amount:   100,
+		Amount:   decimal.RequireFromString("100"),
 		Currency: "EUR",
 	}
 
@@ -33,7 +34,7 @@ func TestExchange_Success(t *testing.T) {
 		})
 
 	req3 := myhttp.ExchangeRequest{
-		Amount:       50,
+		Amount:       decimal.RequireFromString("50"),
 		FromCurrency: "USD",
 		ToCurrency:   "EUR",
 	}
@@ -43,10 +44,10 @@ func TestExchange_Success(t *testing.T) {
 			request.Header.Set("Authorization", "Bearer "+token)
 		})
 
-	exchangedAmount := req3.Amount * rates[models.Currency(req2.Currency)]
-	assert.Equal(t, req1.Amount-req3.Amount, resp.NewBalance[req1.Currency])
-	assert.Equal(t, req2.Amount+exchangedAmount, resp.NewBalance[req2.Currency])
-	assert.Equal(t, exchangedAmount, resp.ExchangedAmount)
+	exchangedAmount := req3.Amount.Mul(rates[models.EUR]).RoundDown(models.EUR.Scale())
+	assertDecimalEqual(t, req1.Amount.Sub(req3.Amount), resp.NewBalance[req1.Currency])
+	assertDecimalEqual(t, req2.Amount.Add(exchangedAmount), resp.NewBalance[req2.Currency])
+	assertDecimalEqual(t, exchangedAmount, resp.ExchangedAmount)
 }
 
 func TestExchange_NegativeAmount(t *testing.T) {
@@ -54,7 +55,7 @@ func TestExchange_NegativeAmount(t *testing.T) {
 	token := getToken(t)
 
 	req1 := myhttp.DepositRequest{
-		Amount:   100,
+		Amount:   decimal.RequireFromString("100"),
 		Currency: "USD",
 	}
 
@@ -64,7 +65,7 @@ func TestExchange_NegativeAmount(t *testing.T) {
 		})
 
 	req2 := myhttp.DepositRequest{
-		Amount:   100,
+		Amount:   decimal.RequireFromString("100"),
 		Currency: "EUR",
 	}
 
@@ -74,7 +75,7 @@ func TestExchange_NegativeAmount(t *testing.T) {
 		})
 
 	req3 := myhttp.ExchangeRequest{
-		Amount:       -50,
+		Amount:       decimal.RequireFromString("-50"),
 		FromCurrency: "USD",
 		ToCurrency:   "EUR",
 	}
@@ -92,7 +93,7 @@ func TestExchange_SameCurrencies(t *testing.T) {
 	token := getToken(t)
 
 	req1 := myhttp.DepositRequest{
-		Amount:   100,
+		Amount:   decimal.RequireFromString("100"),
 		Currency: "USD",
 	}
 
@@ -102,7 +103,7 @@ func TestExchange_SameCurrencies(t *testing.T) {
 		})
 
 	req2 := myhttp.ExchangeRequest{
-		Amount:       50,
+		Amount:       decimal.RequireFromString("50"),
 		FromCurrency: "USD",
 		ToCurrency:   "USD",
 	}
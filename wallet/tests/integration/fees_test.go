@@ -0,0 +1,173 @@
+package integration
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"test-task/wallet/internal/domain/models"
+	myhttp "test-task/wallet/internal/transport/http"
+	"testing"
+)
+
+func TestFees_Percent(t *testing.T) {
+
+	token := getToken(t)
+	auth := func(request *http.Request) {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	req1 := myhttp.DepositRequest{
+		Amount:   decimal.RequireFromString("200"),
+		Currency: "USD",
+	}
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, feeServer, "POST",
+		apiPrefix+"wallet/deposit", req1, http.StatusOK, auth)
+
+	req2 := myhttp.ExchangeRequest{
+		Amount:       decimal.RequireFromString("100"),
+		FromCurrency: "USD",
+		ToCurrency:   "EUR",
+	}
+
+	resp := mustSend[myhttp.ExchangeResponse](t, feeServer, "POST",
+		apiPrefix+"exchange", req2, http.StatusOK, auth)
+
+	fee := decimal.RequireFromString("1")
+	exchangedAmount := req2.Amount.Sub(fee).Mul(rates[models.EUR]).RoundDown(models.EUR.Scale())
+	assertDecimalEqual(t, fee, resp.Fee)
+	assertDecimalEqual(t, exchangedAmount, resp.ExchangedAmount)
+	assertDecimalEqual(t, req1.Amount.Sub(req2.Amount), resp.NewBalance[req1.Currency])
+	assertDecimalEqual(t, exchangedAmount, resp.NewBalance[req2.ToCurrency])
+
+	history := mustSend[myhttp.TransactionsResponse](t, feeServer, "GET",
+		apiPrefix+"wallet/transactions?type=exchange&currency=USD", nil, http.StatusOK, auth)
+
+	if assert.Len(t, history.Transactions, 1) && assert.NotNil(t, history.Transactions[0].Fee) {
+		assertDecimalEqual(t, fee, *history.Transactions[0].Fee)
+	}
+}
+
+func TestFees_Minimum(t *testing.T) {
+
+	token := getToken(t)
+	auth := func(request *http.Request) {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	req1 := myhttp.DepositRequest{
+		Amount:   decimal.RequireFromString("10"),
+		Currency: "USD",
+	}
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, feeServer, "POST",
+		apiPrefix+"wallet/deposit", req1, http.StatusOK, auth)
+
+	req2 := myhttp.ExchangeRequest{
+		Amount:       decimal.RequireFromString("10"),
+		FromCurrency: "USD",
+		ToCurrency:   "EUR",
+	}
+
+	resp := mustSend[myhttp.ExchangeResponse](t, feeServer, "POST",
+		apiPrefix+"exchange", req2, http.StatusOK, auth)
+
+	assertDecimalEqual(t, decimal.RequireFromString("0.50"), resp.Fee)
+}
+
+func TestFees_PairOverride(t *testing.T) {
+
+	token := getToken(t)
+	auth := func(request *http.Request) {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	req1 := myhttp.DepositRequest{
+		Amount:   decimal.RequireFromString("100"),
+		Currency: "EUR",
+	}
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, feeServer, "POST",
+		apiPrefix+"wallet/deposit", req1, http.StatusOK, auth)
+
+	req2 := myhttp.QuoteRequest{
+		Amount:       decimal.RequireFromString("50"),
+		FromCurrency: "EUR",
+		ToCurrency:   "USD",
+	}
+
+	quote := mustSend[myhttp.QuoteResponse](t, feeServer, "POST",
+		apiPrefix+"exchange/quote", req2, http.StatusOK, auth)
+
+	assertDecimalEqual(t, decimal.RequireFromString("2"), quote.Fee)
+
+	resp := mustSend[myhttp.ExchangeResponse](t, feeServer, "POST",
+		apiPrefix+"exchange", myhttp.ExchangeRequest{QuoteID: quote.QuoteID}, http.StatusOK, auth)
+
+	assertDecimalEqual(t, quote.Fee, resp.Fee)
+	assertDecimalEqual(t, quote.ExchangedAmount, resp.ExchangedAmount)
+}
+
+func TestFees_ExceedAmount(t *testing.T) {
+
+	token := getToken(t)
+
+	req := myhttp.ExchangeRequest{
+		Amount:       decimal.RequireFromString("0.40"),
+		FromCurrency: "USD",
+		ToCurrency:   "EUR",
+	}
+
+	_ = mustSend[myhttp.ErrorResponse](t, feeServer, "POST",
+		apiPrefix+"exchange", req, http.StatusBadRequest, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+token)
+		})
+}
+
+func TestFees_CrossCurrencyTransfer(t *testing.T) {
+
+	token := getToken(t)
+	recipient, recipientToken := getUserWithToken(t)
+	auth := func(request *http.Request) {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, feeServer, "POST", apiPrefix+"wallet/deposit",
+		myhttp.DepositRequest{Amount: decimal.RequireFromString("200"), Currency: "USD"}, http.StatusOK, auth)
+
+	req := myhttp.TransferRequest{
+		Recipient:  recipient.Username,
+		Amount:     decimal.RequireFromString("100"),
+		Currency:   "USD",
+		ToCurrency: "EUR",
+	}
+
+	resp := mustSend[myhttp.TransferResponse](t, feeServer, "POST",
+		apiPrefix+"wallet/transfer", req, http.StatusOK, auth)
+
+	// charged like an exchange of the same amount
+	fee := decimal.RequireFromString("1")
+	creditedAmount := req.Amount.Sub(fee).Mul(rates[models.EUR]).RoundDown(models.EUR.Scale())
+	assertDecimalEqual(t, fee, resp.Fee)
+	assertDecimalEqual(t, creditedAmount, resp.CreditedAmount)
+	assertDecimalEqual(t, decimal.RequireFromString("100"), resp.NewBalance["USD"])
+
+	balance := mustSend[myhttp.BalanceResponse](t, feeServer, "GET", apiPrefix+"balance", nil, http.StatusOK,
+		func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+recipientToken)
+		})
+	assertDecimalEqual(t, creditedAmount, balance.Balance["EUR"])
+
+	history := mustSend[myhttp.TransactionsResponse](t, feeServer, "GET",
+		apiPrefix+"wallet/transactions?type=transfer&currency=USD", nil, http.StatusOK, auth)
+
+	if assert.Len(t, history.Transactions, 1) && assert.NotNil(t, history.Transactions[0].Fee) {
+		assertDecimalEqual(t, fee, *history.Transactions[0].Fee)
+	}
+
+	// a transfer in one currency is free
+	same := mustSend[myhttp.TransferResponse](t, feeServer, "POST", apiPrefix+"wallet/transfer",
+		myhttp.TransferRequest{Recipient: recipient.Username, Amount: decimal.RequireFromString("10"), Currency: "USD"},
+		http.StatusOK, auth)
+	assert.True(t, same.Fee.IsZero())
+}
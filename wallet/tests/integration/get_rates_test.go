@@ -12,4 +12,7 @@ func TestGetRates(t *testing.T) {
 	resp := mustSend[myhttp.GetRatesResponse](t, server, "GET", apiPrefix+"exchange/rates", nil,
 		http.StatusOK, nil)
 	assert.Equal(t, resp.Rates, convertRates(rates))
+	assert.Equal(t, "USD", resp.BaseCurrency)
+	assert.Equal(t, int64(1), resp.Version)
+	assert.True(t, mockRatesEffectiveAt.Equal(resp.EffectiveAt))
 }
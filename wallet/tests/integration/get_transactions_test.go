@@ -0,0 +1,148 @@
+package integration
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	myhttp "test-task/wallet/internal/transport/http"
+	"testing"
+)
+
+func TestGetTransactions_Success(t *testing.T) {
+
+	token := getToken(t)
+
+	req1 := myhttp.DepositRequest{
+		Amount:   decimal.RequireFromString("100"),
+		Currency: "USD",
+	}
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST",
+		apiPrefix+"wallet/deposit", req1, http.StatusOK, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+token)
+		})
+
+	req2 := myhttp.WithdrawRequest{
+		Amount:   decimal.RequireFromString("30"),
+		Currency: "USD",
+	}
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST",
+		apiPrefix+"wallet/withdraw", req2, http.StatusOK, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+token)
+		})
+
+	req3 := myhttp.ExchangeRequest{
+		Amount:       decimal.RequireFromString("50"),
+		FromCurrency: "USD",
+		ToCurrency:   "EUR",
+	}
+
+	_ = mustSend[myhttp.ExchangeResponse](t, server, "POST",
+		apiPrefix+"exchange", req3, http.StatusOK, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+token)
+		})
+
+	resp := mustSend[myhttp.TransactionsResponse](t, server, "GET",
+		apiPrefix+"wallet/transactions", nil, http.StatusOK, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+token)
+		})
+
+	require.Len(t, resp.Transactions, 4)
+	assert.Empty(t, resp.NextCursor)
+
+	toLeg, fromLeg := resp.Transactions[0], resp.Transactions[1]
+	assert.Equal(t, "exchange", toLeg.Type)
+	assert.Equal(t, req3.ToCurrency, toLeg.Currency)
+	assert.Equal(t, fromLeg.ID, *toLeg.CounterpartyID)
+	assert.Equal(t, toLeg.ID, *fromLeg.CounterpartyID)
+	assertDecimalEqual(t, req3.Amount.Neg(), fromLeg.Amount)
+	assertDecimalEqual(t, req1.Amount.Sub(req2.Amount).Sub(req3.Amount), fromLeg.BalanceAfter)
+
+	assert.Equal(t, "withdraw", resp.Transactions[2].Type)
+	assertDecimalEqual(t, req2.Amount.Neg(), resp.Transactions[2].Amount)
+	assert.Equal(t, "deposit", resp.Transactions[3].Type)
+	assertDecimalEqual(t, req1.Amount, resp.Transactions[3].BalanceAfter)
+}
+
+func TestGetTransactions_Pagination(t *testing.T) {
+
+	token := getToken(t)
+
+	req := myhttp.DepositRequest{
+		Amount:   decimal.RequireFromString("10"),
+		Currency: "RUB",
+	}
+
+	for i := 0; i < 5; i++ {
+		_ = mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST",
+			apiPrefix+"wallet/deposit", req, http.StatusOK, func(request *http.Request) {
+				request.Header.Set("Authorization", "Bearer "+token)
+			})
+	}
+
+	var ids []int64
+	url := apiPrefix + "wallet/transactions?limit=2&type=deposit"
+	for {
+		resp := mustSend[myhttp.TransactionsResponse](t, server, "GET", url, nil, http.StatusOK,
+			func(request *http.Request) {
+				request.Header.Set("Authorization", "Bearer "+token)
+			})
+
+		for _, transaction := range resp.Transactions {
+			ids = append(ids, transaction.ID)
+		}
+		if resp.NextCursor == "" {
+			break
+		}
+		url = apiPrefix + "wallet/transactions?limit=2&type=deposit&cursor=" + resp.NextCursor
+	}
+
+	require.Len(t, ids, 5)
+	for i := 1; i < len(ids); i++ {
+		assert.Greater(t, ids[i-1], ids[i])
+	}
+}
+
+func TestGetTransactions_FilterByCurrency(t *testing.T) {
+
+	token := getToken(t)
+
+	for _, currency := range []string{"USD", "EUR"} {
+		req := myhttp.DepositRequest{
+			Amount:   decimal.RequireFromString("10"),
+			Currency: currency,
+		}
+		_ = mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST",
+			apiPrefix+"wallet/deposit", req, http.StatusOK, func(request *http.Request) {
+				request.Header.Set("Authorization", "Bearer "+token)
+			})
+	}
+
+	resp := mustSend[myhttp.TransactionsResponse](t, server, "GET",
+		apiPrefix+"wallet/transactions?currency=EUR", nil, http.StatusOK, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+token)
+		})
+
+	require.Len(t, resp.Transactions, 1)
+	assert.Equal(t, "EUR", resp.Transactions[0].Currency)
+}
+
+func TestGetTransactions_InvalidFilter(t *testing.T) {
+
+	token := getToken(t)
+
+	resp := mustSend[myhttp.ErrorResponse](t, server, "GET",
+		apiPrefix+"wallet/transactions?type=refund", nil, http.StatusBadRequest, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+token)
+		})
+
+	assert.Equal(t, "Invalid filter", resp.Error)
+}
+
+func TestGetTransactions_MissingToken(t *testing.T) {
+
+	_ = mustSend[myhttp.ErrorResponse](t, server, "GET",
+		apiPrefix+"wallet/transactions", nil, http.StatusUnauthorized, nil)
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
@@ -51,14 +53,18 @@ func returnResponseWithCheck[Res any](t require.TestingT, resp *Res, err error)
 	return resp
 }
 
-func convertRates(rates map[models.Currency]float64) map[string]float64 {
-	res := map[string]float64{}
+func convertRates(rates map[models.Currency]decimal.Decimal) map[string]decimal.Decimal {
+	res := map[string]decimal.Decimal{}
 	for k, v := range rates {
 		res[string(k)] = v
 	}
 	return res
 }
 
+func assertDecimalEqual(t require.TestingT, expected decimal.Decimal, actual decimal.Decimal) {
+	assert.True(t, expected.Equal(actual), "expected %s, got %s", expected, actual)
+}
+
 var registerRequestGenerator = createRegisterRequestGenerator()
 
 func createRegisterRequestGenerator() func() myhttp.RegisterRequest {
@@ -78,6 +84,21 @@ func createRegisterRequestGenerator() func() myhttp.RegisterRequest {
 }
 
 func getToken(t *testing.T) string {
+	_, token := getUserWithToken(t)
+	return token
+}
+
+func getUserWithToken(t *testing.T) (myhttp.RegisterRequest, string) {
+	registerReq, resp := registerAndLogin(t)
+	return registerReq, resp.Token
+}
+
+func login(t *testing.T) *myhttp.LoginResponse {
+	_, resp := registerAndLogin(t)
+	return resp
+}
+
+func registerAndLogin(t *testing.T) (myhttp.RegisterRequest, *myhttp.LoginResponse) {
 	registerReq := registerRequestGenerator()
 
 	_ = mustSend[myhttp.SuccessResponse](t, server, "POST",
@@ -90,5 +111,5 @@ func getToken(t *testing.T) string {
 
 	resp := mustSend[myhttp.LoginResponse](t, server, "POST",
 		apiPrefix+"login", loginReq, http.StatusOK, nil)
-	return resp.Token
+	return registerReq, resp
 }
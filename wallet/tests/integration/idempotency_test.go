@@ -0,0 +1,111 @@
+package integration
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	myhttp "test-task/wallet/internal/transport/http"
+	"testing"
+)
+
+func TestIdempotency_ReplayReturnsOriginalResponse(t *testing.T) {
+
+	token := getToken(t)
+	req := myhttp.DepositRequest{
+		Amount:   decimal.RequireFromString("100"),
+		Currency: "USD",
+	}
+	withKey := func(request *http.Request) {
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("Idempotency-Key", "deposit-1")
+	}
+
+	resp1 := mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST",
+		apiPrefix+"wallet/deposit", req, http.StatusOK, withKey)
+
+	resp2 := mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST",
+		apiPrefix+"wallet/deposit", req, http.StatusOK, withKey)
+
+	assertDecimalEqual(t, req.Amount, resp1.NewBalance[req.Currency])
+	assertDecimalEqual(t, req.Amount, resp2.NewBalance[req.Currency])
+
+	balance := mustSend[myhttp.BalanceResponse](t, server, "GET",
+		apiPrefix+"balance", nil, http.StatusOK, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+token)
+		})
+
+	assertDecimalEqual(t, req.Amount, balance.Balance[req.Currency])
+}
+
+func TestIdempotency_ReplayOfFailedRequest(t *testing.T) {
+
+	token := getToken(t)
+	req := myhttp.WithdrawRequest{
+		Amount:   decimal.RequireFromString("50"),
+		Currency: "USD",
+	}
+	withKey := func(request *http.Request) {
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("Idempotency-Key", "withdraw-1")
+	}
+
+	_ = mustSend[myhttp.ErrorResponse](t, server, "POST",
+		apiPrefix+"wallet/withdraw", req, http.StatusBadRequest, withKey)
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST",
+		apiPrefix+"wallet/deposit", myhttp.DepositRequest{Amount: req.Amount, Currency: req.Currency},
+		http.StatusOK, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+token)
+		})
+
+	resp := mustSend[myhttp.ErrorResponse](t, server, "POST",
+		apiPrefix+"wallet/withdraw", req, http.StatusBadRequest, withKey)
+
+	assert.Equal(t, "Insufficient funds", resp.Error)
+}
+
+func TestIdempotency_ConflictingReplay(t *testing.T) {
+
+	token := getToken(t)
+	withKey := func(request *http.Request) {
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("Idempotency-Key", "deposit-2")
+	}
+
+	req1 := myhttp.DepositRequest{
+		Amount:   decimal.RequireFromString("100"),
+		Currency: "USD",
+	}
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST",
+		apiPrefix+"wallet/deposit", req1, http.StatusOK, withKey)
+
+	req2 := myhttp.DepositRequest{
+		Amount:   decimal.RequireFromString("200"),
+		Currency: "USD",
+	}
+
+	resp := mustSend[myhttp.ErrorResponse](t, server, "POST",
+		apiPrefix+"wallet/deposit", req2, http.StatusUnprocessableEntity, withKey)
+
+	assert.Equal(t, "Idempotency key was already used for a different request", resp.Error)
+}
+
+func TestIdempotency_KeysAreScopedPerUser(t *testing.T) {
+
+	req := myhttp.DepositRequest{
+		Amount:   decimal.RequireFromString("100"),
+		Currency: "USD",
+	}
+
+	for i := 0; i < 2; i++ {
+		token := getToken(t)
+		resp := mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST",
+			apiPrefix+"wallet/deposit", req, http.StatusOK, func(request *http.Request) {
+				request.Header.Set("Authorization", "Bearer "+token)
+				request.Header.Set("Idempotency-Key", "shared-key")
+			})
+
+		assertDecimalEqual(t, req.Amount, resp.NewBalance[req.Currency])
+	}
+}
@@ -0,0 +1,39 @@
+package integration
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	myhttp "test-task/wallet/internal/transport/http"
+	"testing"
+)
+
+func TestJWKS_VerifiesIssuedTokens(t *testing.T) {
+
+	token := getToken(t)
+
+	resp := mustSend[myhttp.JWKSResponse](t, server, "GET", "/.well-known/jwks.json", nil, http.StatusOK, nil)
+	require.NotEmpty(t, resp.Keys)
+
+	keys := map[string]myhttp.JWK{}
+	for _, key := range resp.Keys {
+		assert.Equal(t, "sig", key.Use)
+		keys[key.KeyID] = key
+	}
+
+	// a verifier needs nothing but the published keys
+	parsed, err := jwt.Parse(token, func(token *jwt.Token) (any, error) {
+		key := keys[token.Header["kid"].(string)]
+		require.Equal(t, "OKP", key.KeyType)
+		require.Equal(t, "Ed25519", key.Curve)
+
+		x, err := base64.RawURLEncoding.DecodeString(key.X)
+		require.NoError(t, err)
+		return ed25519.PublicKey(x), nil
+	}, jwt.WithValidMethods([]string{"EdDSA"}))
+	require.NoError(t, err)
+	assert.True(t, parsed.Valid)
+}
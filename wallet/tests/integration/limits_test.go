@@ -0,0 +1,136 @@
+package integration
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	myhttp "test-task/wallet/internal/transport/http"
+	"testing"
+)
+
+func TestLimits_Withdraw(t *testing.T) {
+
+	token := getToken(t)
+	auth := func(request *http.Request) {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	req1 := myhttp.DepositRequest{
+		Amount:   decimal.RequireFromString("500"),
+		Currency: "USD",
+	}
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, limitServer, "POST",
+		apiPrefix+"wallet/deposit", req1, http.StatusOK, auth)
+
+	overOperation := myhttp.WithdrawRequest{
+		Amount:   decimal.RequireFromString("101"),
+		Currency: "USD",
+	}
+
+	limitResp := mustSend[myhttp.ErrorResponse](t, limitServer, "POST",
+		apiPrefix+"wallet/withdraw", overOperation, http.StatusTooManyRequests, auth)
+	assert.Equal(t, "Limit exceeded", limitResp.Error)
+
+	req2 := myhttp.WithdrawRequest{
+		Amount:   decimal.RequireFromString("100"),
+		Currency: "USD",
+	}
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, limitServer, "POST",
+		apiPrefix+"wallet/withdraw", req2, http.StatusOK, auth)
+
+	overDaily := myhttp.WithdrawRequest{
+		Amount:   decimal.RequireFromString("60"),
+		Currency: "USD",
+	}
+
+	_ = mustSend[myhttp.ErrorResponse](t, limitServer, "POST",
+		apiPrefix+"wallet/withdraw", overDaily, http.StatusTooManyRequests, auth)
+
+	req3 := myhttp.WithdrawRequest{
+		Amount:   decimal.RequireFromString("50"),
+		Currency: "USD",
+	}
+
+	resp := mustSend[myhttp.UpdatedBalanceResponse](t, limitServer, "POST",
+		apiPrefix+"wallet/withdraw", req3, http.StatusOK, auth)
+
+	assertDecimalEqual(t, req1.Amount.Sub(req2.Amount).Sub(req3.Amount), resp.NewBalance[req1.Currency])
+}
+
+func TestLimits_Exchange(t *testing.T) {
+
+	token := getToken(t)
+	auth := func(request *http.Request) {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	req1 := myhttp.DepositRequest{
+		Amount:   decimal.RequireFromString("100"),
+		Currency: "USD",
+	}
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, limitServer, "POST",
+		apiPrefix+"wallet/deposit", req1, http.StatusOK, auth)
+
+	req2 := myhttp.ExchangeRequest{
+		Amount:       decimal.RequireFromString("40"),
+		FromCurrency: "USD",
+		ToCurrency:   "EUR",
+	}
+
+	_ = mustSend[myhttp.ExchangeResponse](t, limitServer, "POST",
+		apiPrefix+"exchange", req2, http.StatusOK, auth)
+
+	_ = mustSend[myhttp.ErrorResponse](t, limitServer, "POST",
+		apiPrefix+"exchange", req2, http.StatusTooManyRequests, auth)
+
+	balance := mustSend[myhttp.BalanceResponse](t, limitServer, "GET",
+		apiPrefix+"balance", nil, http.StatusOK, auth)
+
+	assertDecimalEqual(t, req1.Amount.Sub(req2.Amount), balance.Balance[req1.Currency])
+}
+
+func TestLimits_Transfer(t *testing.T) {
+
+	token := getToken(t)
+	recipient, _ := getUserWithToken(t)
+	auth := func(request *http.Request) {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	transfer := func(amount string, toCurrency string) myhttp.TransferRequest {
+		return myhttp.TransferRequest{
+			Recipient:  recipient.Username,
+			Amount:     decimal.RequireFromString(amount),
+			Currency:   "USD",
+			ToCurrency: toCurrency,
+		}
+	}
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, limitServer, "POST", apiPrefix+"wallet/deposit",
+		myhttp.DepositRequest{Amount: decimal.RequireFromString("500"), Currency: "USD"}, http.StatusOK, auth)
+
+	// a transfer is limited like a withdrawal
+	_ = mustSend[myhttp.ErrorResponse](t, limitServer, "POST", apiPrefix+"wallet/transfer", transfer("101", ""),
+		http.StatusTooManyRequests, auth)
+	_ = mustSend[myhttp.TransferResponse](t, limitServer, "POST", apiPrefix+"wallet/transfer", transfer("100", ""),
+		http.StatusOK, auth)
+
+	// and counts towards the daily withdrawals
+	_ = mustSend[myhttp.ErrorResponse](t, limitServer, "POST", apiPrefix+"wallet/withdraw",
+		myhttp.WithdrawRequest{Amount: decimal.RequireFromString("60"), Currency: "USD"},
+		http.StatusTooManyRequests, auth)
+
+	// a converted transfer counts towards the exchanges as well
+	_ = mustSend[myhttp.TransferResponse](t, limitServer, "POST", apiPrefix+"wallet/transfer", transfer("40", "EUR"),
+		http.StatusOK, auth)
+	_ = mustSend[myhttp.ErrorResponse](t, limitServer, "POST", apiPrefix+"exchange",
+		myhttp.ExchangeRequest{Amount: decimal.RequireFromString("20"), FromCurrency: "USD", ToCurrency: "EUR"},
+		http.StatusTooManyRequests, auth)
+	_ = mustSend[myhttp.ErrorResponse](t, limitServer, "POST", apiPrefix+"wallet/transfer", transfer("11", "EUR"),
+		http.StatusTooManyRequests, auth)
+
+	balance := mustSend[myhttp.BalanceResponse](t, limitServer, "GET", apiPrefix+"balance", nil, http.StatusOK, auth)
+	assertDecimalEqual(t, decimal.RequireFromString("360"), balance.Balance["USD"])
+}
@@ -0,0 +1,83 @@
+package integration
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	myhttp "test-task/wallet/internal/transport/http"
+	"testing"
+)
+
+func withToken(token string) func(*http.Request) {
+	return func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+func TestLogout_RevokesToken(t *testing.T) {
+
+	tokens := login(t)
+
+	_ = mustSend[myhttp.SuccessResponse](t, server, "POST", apiPrefix+"logout", nil, http.StatusOK,
+		withToken(tokens.Token))
+
+	resp := mustSend[myhttp.ErrorResponse](t, server, "GET", apiPrefix+"balance", nil, http.StatusUnauthorized,
+		withToken(tokens.Token))
+	assert.Equal(t, "Token revoked", resp.Error)
+
+	// without the refresh token the session goes on
+	refreshed := mustSend[myhttp.LoginResponse](t, server, "POST", apiPrefix+"token/refresh",
+		myhttp.RefreshRequest{RefreshToken: tokens.RefreshToken}, http.StatusOK, nil)
+	_ = mustSend[myhttp.BalanceResponse](t, server, "GET", apiPrefix+"balance", nil, http.StatusOK,
+		withToken(refreshed.Token))
+}
+
+func TestLogout_WithRefreshToken_EndsSession(t *testing.T) {
+
+	tokens := login(t)
+
+	_ = mustSend[myhttp.SuccessResponse](t, server, "POST", apiPrefix+"logout",
+		myhttp.LogoutRequest{RefreshToken: tokens.RefreshToken}, http.StatusOK, withToken(tokens.Token))
+
+	resp := mustSend[myhttp.ErrorResponse](t, server, "POST", apiPrefix+"token/refresh",
+		myhttp.RefreshRequest{RefreshToken: tokens.RefreshToken}, http.StatusUnauthorized, nil)
+	assert.Equal(t, "Invalid refresh token", resp.Error)
+}
+
+func TestLogout_OtherSessionsStay(t *testing.T) {
+
+	registerReq, first := registerAndLogin(t)
+	second := mustSend[myhttp.LoginResponse](t, server, "POST", apiPrefix+"login",
+		myhttp.LoginRequest{Username: registerReq.Username, Password: registerReq.Password}, http.StatusOK, nil)
+
+	_ = mustSend[myhttp.SuccessResponse](t, server, "POST", apiPrefix+"logout",
+		myhttp.LogoutRequest{RefreshToken: first.RefreshToken}, http.StatusOK, withToken(first.Token))
+
+	_ = mustSend[myhttp.LoginResponse](t, server, "POST", apiPrefix+"token/refresh",
+		myhttp.RefreshRequest{RefreshToken: second.RefreshToken}, http.StatusOK, nil)
+}
+
+func TestLogoutAll_RevokesEverySession(t *testing.T) {
+
+	registerReq, first := registerAndLogin(t)
+	loginReq := myhttp.LoginRequest{Username: registerReq.Username, Password: registerReq.Password}
+	second := mustSend[myhttp.LoginResponse](t, server, "POST", apiPrefix+"login", loginReq, http.StatusOK, nil)
+
+	_ = mustSend[myhttp.SuccessResponse](t, server, "POST", apiPrefix+"logout/all", nil, http.StatusOK,
+		withToken(first.Token))
+
+	for _, tokens := range []*myhttp.LoginResponse{first, second} {
+		_ = mustSend[myhttp.ErrorResponse](t, server, "GET", apiPrefix+"balance", nil, http.StatusUnauthorized,
+			withToken(tokens.Token))
+		_ = mustSend[myhttp.ErrorResponse](t, server, "POST", apiPrefix+"token/refresh",
+			myhttp.RefreshRequest{RefreshToken: tokens.RefreshToken}, http.StatusUnauthorized, nil)
+	}
+
+	third := mustSend[myhttp.LoginResponse](t, server, "POST", apiPrefix+"login", loginReq, http.StatusOK, nil)
+	_ = mustSend[myhttp.BalanceResponse](t, server, "GET", apiPrefix+"balance", nil, http.StatusOK,
+		withToken(third.Token))
+}
+
+func TestLogout_WithoutToken(t *testing.T) {
+
+	_ = mustSend[myhttp.ErrorResponse](t, server, "POST", apiPrefix+"logout", nil, http.StatusUnauthorized, nil)
+}
@@ -2,64 +2,220 @@ package integration
 
 import (
 	"context"
+	"errors"
+	"github.com/shopspring/decimal"
+	"sync"
 	errs "test-task/wallet/internal/domain/errors"
 	"test-task/wallet/internal/domain/models"
 	"time"
 )
 
 type redisMock struct {
+	mu     sync.Mutex
+	quotes map[string]models.Quote
 }
 
-func (r redisMock) StoreRate(ctx context.Context, from models.Currency, to models.Currency, value float64, expiration time.Duration) error {
+func newRedisMock() *redisMock {
+	return &redisMock{quotes: make(map[string]models.Quote)}
+}
+
+func (r *redisMock) StoreRate(ctx context.Context, from models.Currency, to models.Currency, value decimal.Decimal, expiration time.Duration) error {
 	return nil
 }
 
-func (r redisMock) StoreRates(ctx context.Context, rates map[models.Currency]float64, base models.Currency, expiration time.Duration) error {
+func (r *redisMock) StoreRates(ctx context.Context, rates *models.ExchangeRates, expiration time.Duration) error {
 	return nil
 }
 
-func (r redisMock) GetRate(ctx context.Context, from models.Currency, to models.Currency) (float64, error) {
-	return 0, errs.KeyNotExists
+func (r *redisMock) GetRate(ctx context.Context, from models.Currency, to models.Currency) (decimal.Decimal, error) {
+	return decimal.Zero, errs.KeyNotExists
+}
+
+func (r *redisMock) ReplaceRates(ctx context.Context, rates *models.ExchangeRates, expiration time.Duration) error {
+	return nil
 }
 
-func (r redisMock) GetRates(ctx context.Context, base models.Currency) (map[models.Currency]float64, error) {
+func (r *redisMock) GetRates(ctx context.Context) (*models.ExchangeRates, error) {
 	return nil, errs.KeyNotExists
 }
 
+func (r *redisMock) StoreQuote(ctx context.Context, quote *models.Quote, expiration time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.quotes[quote.UserID+":"+quote.ID] = *quote
+	return nil
+}
+
+func (r *redisMock) TakeQuote(ctx context.Context, userID, id string) (*models.Quote, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	quote, ok := r.quotes[userID+":"+id]
+	if !ok {
+		return nil, errs.KeyNotExists
+	}
+	delete(r.quotes, userID+":"+id)
+	return &quote, nil
+}
+
+var mockRatesEffectiveAt = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
 type exchangerClientMock struct {
 	base  models.Currency
-	rates map[models.Currency]float64
+	rates map[models.Currency]decimal.Decimal
 }
 
-func newExchangerClientMock(base models.Currency, rates map[models.Currency]float64) *exchangerClientMock {
+func newExchangerClientMock(base models.Currency, rates map[models.Currency]decimal.Decimal) *exchangerClientMock {
 	return &exchangerClientMock{base: base, rates: rates}
 }
 
-func (e exchangerClientMock) GetExchangeRates(ctx context.Context) (map[models.Currency]float64, error) {
-	return e.rates, nil
+func (e exchangerClientMock) GetExchangeRates(ctx context.Context) (*models.ExchangeRates, error) {
+	return e.exchangeRates(), nil
 }
 
-func (e exchangerClientMock) GetExchangeRateForOne(ctx context.Context, from models.Currency, to models.Currency) (float64, error) {
+func (e exchangerClientMock) GetExchangeRateForOne(ctx context.Context, from models.Currency, to models.Currency) (decimal.Decimal, error) {
 	if from == to {
-		return 1, nil
+		return decimal.NewFromInt(1), nil
 	}
 
 	if from == e.base {
 		return e.getRate(to), nil
 	}
 	if to == e.base {
-		return 1 / e.getRate(from), nil
+		return decimal.NewFromInt(1).Div(e.getRate(from)), nil
 	}
 	from_rate := e.getRate(from)
 	to_rate := e.getRate(to)
-	return from_rate / to_rate, nil
+	return to_rate.Div(from_rate), nil
+}
+
+func (e exchangerClientMock) StreamExchangeRates(ctx context.Context, onUpdate func(*models.ExchangeRates)) error {
+	onUpdate(e.exchangeRates())
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (e exchangerClientMock) exchangeRates() *models.ExchangeRates {
+	return &models.ExchangeRates{Rates: e.rates, Base: e.base, Version: 1, EffectiveAt: mockRatesEffectiveAt}
 }
 
-func (e exchangerClientMock) getRate(currency models.Currency) float64 {
+func (e exchangerClientMock) getRate(currency models.Currency) decimal.Decimal {
 	for k, v := range e.rates {
 		if k == currency {
 			return v
 		}
 	}
-	return 0
+	return decimal.Zero
+}
+
+type idempotencyStoreMock struct {
+	mu      sync.Mutex
+	records map[string]models.IdempotencyRecord
+}
+
+func newIdempotencyStoreMock() *idempotencyStoreMock {
+	return &idempotencyStoreMock{records: make(map[string]models.IdempotencyRecord)}
+}
+
+func (s *idempotencyStoreMock) ReserveIdempotencyKey(ctx context.Context, userID, key, fingerprint string,
+	lifetime time.Duration) (*models.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[userID+":"+key]; ok {
+		return &record, nil
+	}
+	s.records[userID+":"+key] = models.IdempotencyRecord{Fingerprint: fingerprint}
+	return nil, nil
+}
+
+func (s *idempotencyStoreMock) CompleteIdempotencyKey(ctx context.Context, userID, key string,
+	record models.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[userID+":"+key] = record
+	return nil
+}
+
+func (s *idempotencyStoreMock) ReleaseIdempotencyKey(ctx context.Context, userID, key, fingerprint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[userID+":"+key]; ok && !record.IsCompleted() && record.Fingerprint == fingerprint {
+		delete(s.records, userID+":"+key)
+	}
+	return nil
+}
+
+type revocationStoreMock struct {
+	mu           sync.Mutex
+	tokens       map[string]struct{}
+	issuedBefore map[string]time.Time
+	failUserID   string // RevokeUserTokens fails for this user
+}
+
+func (s *revocationStoreMock) failUser(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failUserID = userID
+}
+
+func newRevocationStoreMock() *revocationStoreMock {
+	return &revocationStoreMock{tokens: make(map[string]struct{}), issuedBefore: make(map[string]time.Time)}
+}
+
+func (s *revocationStoreMock) RevokeToken(ctx context.Context, id string, expiration time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[id] = struct{}{}
+	return nil
+}
+
+func (s *revocationStoreMock) RevokeUserTokens(ctx context.Context, userID string, issuedBefore time.Time,
+	expiration time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if userID == s.failUserID {
+		return errors.New("revocation store unavailable")
+	}
+	s.issuedBefore[userID] = issuedBefore
+	return nil
+}
+
+func (s *revocationStoreMock) IsTokenRevoked(ctx context.Context, userID, id string, issuedAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tokens[id]; ok {
+		return true, nil
+	}
+	issuedBefore, ok := s.issuedBefore[userID]
+	return ok && issuedAt.UnixMilli() <= issuedBefore.UnixMilli(), nil
+}
+
+type keyRepositoryMock struct {
+	mu   sync.Mutex
+	keys []models.SigningKey
+}
+
+func newKeyRepositoryMock() *keyRepositoryMock {
+	return &keyRepositoryMock{}
+}
+
+func (r *keyRepositoryMock) AddSigningKey(ctx context.Context, key *models.SigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys = append(r.keys, *key)
+	return nil
+}
+
+func (r *keyRepositoryMock) GetSigningKeys(ctx context.Context, createdAfter time.Time) ([]models.SigningKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var res []models.SigningKey
+	for _, key := range r.keys {
+		if key.CreatedAt.After(createdAfter) {
+			res = append(res, key)
+		}
+	}
+	return res, nil
 }
@@ -0,0 +1,108 @@
+package integration
+
+import (
+	"github.com/shopspring/decimal"
+	"net/http"
+	"test-task/wallet/internal/domain/models"
+	myhttp "test-task/wallet/internal/transport/http"
+	"testing"
+)
+
+func TestQuote_ExchangeAtQuotedRate(t *testing.T) {
+
+	token := getToken(t)
+	auth := func(request *http.Request) {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	req1 := myhttp.DepositRequest{
+		Amount:   decimal.RequireFromString("100"),
+		Currency: "USD",
+	}
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST",
+		apiPrefix+"wallet/deposit", req1, http.StatusOK, auth)
+
+	req2 := myhttp.QuoteRequest{
+		Amount:       decimal.RequireFromString("50"),
+		FromCurrency: "USD",
+		ToCurrency:   "EUR",
+	}
+
+	quote := mustSend[myhttp.QuoteResponse](t, server, "POST",
+		apiPrefix+"exchange/quote", req2, http.StatusOK, auth)
+
+	exchangedAmount := req2.Amount.Mul(rates[models.EUR]).RoundDown(models.EUR.Scale())
+	assertDecimalEqual(t, rates[models.EUR], quote.Rate)
+	assertDecimalEqual(t, exchangedAmount, quote.ExchangedAmount)
+
+	req3 := myhttp.ExchangeRequest{QuoteID: quote.QuoteID}
+
+	resp := mustSend[myhttp.ExchangeResponse](t, server, "POST",
+		apiPrefix+"exchange", req3, http.StatusOK, auth)
+
+	assertDecimalEqual(t, quote.ExchangedAmount, resp.ExchangedAmount)
+	assertDecimalEqual(t, req1.Amount.Sub(req2.Amount), resp.NewBalance[req1.Currency])
+	assertDecimalEqual(t, quote.ExchangedAmount, resp.NewBalance[req2.ToCurrency])
+}
+
+func TestQuote_SingleUse(t *testing.T) {
+
+	token := getToken(t)
+	auth := func(request *http.Request) {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	req1 := myhttp.DepositRequest{
+		Amount:   decimal.RequireFromString("100"),
+		Currency: "USD",
+	}
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST",
+		apiPrefix+"wallet/deposit", req1, http.StatusOK, auth)
+
+	req2 := myhttp.QuoteRequest{
+		Amount:       decimal.RequireFromString("10"),
+		FromCurrency: "USD",
+		ToCurrency:   "EUR",
+	}
+
+	quote := mustSend[myhttp.QuoteResponse](t, server, "POST",
+		apiPrefix+"exchange/quote", req2, http.StatusOK, auth)
+
+	req3 := myhttp.ExchangeRequest{QuoteID: quote.QuoteID}
+
+	_ = mustSend[myhttp.ExchangeResponse](t, server, "POST",
+		apiPrefix+"exchange", req3, http.StatusOK, auth)
+
+	_ = mustSend[myhttp.ErrorResponse](t, server, "POST",
+		apiPrefix+"exchange", req3, http.StatusNotFound, auth)
+}
+
+func TestQuote_Unknown(t *testing.T) {
+
+	token := getToken(t)
+
+	req := myhttp.ExchangeRequest{QuoteID: "unknown"}
+
+	_ = mustSend[myhttp.ErrorResponse](t, server, "POST",
+		apiPrefix+"exchange", req, http.StatusNotFound, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+token)
+		})
+}
+
+func TestQuote_InvalidCurrency(t *testing.T) {
+
+	token := getToken(t)
+
+	req := myhttp.QuoteRequest{
+		Amount:       decimal.RequireFromString("10"),
+		FromCurrency: "USD",
+		ToCurrency:   "USD",
+	}
+
+	_ = mustSend[myhttp.ErrorResponse](t, server, "POST",
+		apiPrefix+"exchange/quote", req, http.StatusBadRequest, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+token)
+		})
+}
@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	myhttp "test-task/wallet/internal/transport/http"
+	"testing"
+)
+
+func TestRefresh_Success(t *testing.T) {
+
+	tokens := login(t)
+	assert.NotEmpty(t, tokens.RefreshToken)
+
+	refreshed := mustSend[myhttp.LoginResponse](t, server, "POST", apiPrefix+"token/refresh",
+		myhttp.RefreshRequest{RefreshToken: tokens.RefreshToken}, http.StatusOK, nil)
+	assert.NotEmpty(t, refreshed.Token)
+	assert.NotEmpty(t, refreshed.RefreshToken)
+	assert.NotEqual(t, tokens.RefreshToken, refreshed.RefreshToken)
+
+	_ = mustSend[myhttp.BalanceResponse](t, server, "GET", apiPrefix+"balance", nil, http.StatusOK,
+		func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer "+refreshed.Token)
+		})
+
+	// the rotated token can be refreshed again
+	_ = mustSend[myhttp.LoginResponse](t, server, "POST", apiPrefix+"token/refresh",
+		myhttp.RefreshRequest{RefreshToken: refreshed.RefreshToken}, http.StatusOK, nil)
+}
+
+func TestRefresh_Reused_RevokesFamily(t *testing.T) {
+
+	tokens := login(t)
+
+	refreshed := mustSend[myhttp.LoginResponse](t, server, "POST", apiPrefix+"token/refresh",
+		myhttp.RefreshRequest{RefreshToken: tokens.RefreshToken}, http.StatusOK, nil)
+
+	resp := mustSend[myhttp.ErrorResponse](t, server, "POST", apiPrefix+"token/refresh",
+		myhttp.RefreshRequest{RefreshToken: tokens.RefreshToken}, http.StatusUnauthorized, nil)
+	assert.Equal(t, "Refresh token reused, please log in again", resp.Error)
+
+	// the token issued by the first refresh belongs to the revoked family
+	resp = mustSend[myhttp.ErrorResponse](t, server, "POST", apiPrefix+"token/refresh",
+		myhttp.RefreshRequest{RefreshToken: refreshed.RefreshToken}, http.StatusUnauthorized, nil)
+	assert.Equal(t, "Invalid refresh token", resp.Error)
+}
+
+func TestRefresh_OtherLoginsNotRevoked(t *testing.T) {
+
+	registerReq, _ := getUserWithToken(t)
+
+	first := mustSend[myhttp.LoginResponse](t, server, "POST", apiPrefix+"login",
+		myhttp.LoginRequest{Username: registerReq.Username, Password: registerReq.Password}, http.StatusOK, nil)
+	second := mustSend[myhttp.LoginResponse](t, server, "POST", apiPrefix+"login",
+		myhttp.LoginRequest{Username: registerReq.Username, Password: registerReq.Password}, http.StatusOK, nil)
+
+	_ = mustSend[myhttp.LoginResponse](t, server, "POST", apiPrefix+"token/refresh",
+		myhttp.RefreshRequest{RefreshToken: first.RefreshToken}, http.StatusOK, nil)
+	_ = mustSend[myhttp.ErrorResponse](t, server, "POST", apiPrefix+"token/refresh",
+		myhttp.RefreshRequest{RefreshToken: first.RefreshToken}, http.StatusUnauthorized, nil)
+
+	_ = mustSend[myhttp.LoginResponse](t, server, "POST", apiPrefix+"token/refresh",
+		myhttp.RefreshRequest{RefreshToken: second.RefreshToken}, http.StatusOK, nil)
+}
+
+func TestRefresh_UnknownToken(t *testing.T) {
+
+	resp := mustSend[myhttp.ErrorResponse](t, server, "POST", apiPrefix+"token/refresh",
+		myhttp.RefreshRequest{RefreshToken: "unknown"}, http.StatusUnauthorized, nil)
+	assert.Equal(t, "Invalid refresh token", resp.Error)
+}
+
+func TestRefresh_EmptyToken(t *testing.T) {
+
+	_ = mustSend[myhttp.ErrorResponse](t, server, "POST", apiPrefix+"token/refresh",
+		myhttp.RefreshRequest{}, http.StatusBadRequest, nil)
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 	"log"
 	"os"
+	"path/filepath"
 	"test-task/wallet/internal/app"
 	"test-task/wallet/internal/config"
 	"test-task/wallet/internal/domain/models"
@@ -18,11 +20,45 @@ import (
 )
 
 var server *echo.Echo
+var feeServer *echo.Echo   // same storage as server, but charges exchange fees
+var limitServer *echo.Echo // same storage as server, but limits withdrawals and exchanges
 var dbContainer testcontainers.Container
-var rates = map[models.Currency]float64{
-	models.USD: 1,
-	models.EUR: 0.85,
-	models.RUB: 0.1,
+var sqliteDir string
+
+// jwtConfig and keyRepository are those of the servers, to issue tokens they should reject
+var jwtConfig services.JWTConfig
+var keyRepository services.SigningKeyRepository
+
+// adminService is that of server, to grant the admin role
+var adminService *services.AdminService
+
+// revocations is the revocation store of the servers, to make it fail
+var revocations *revocationStoreMock
+
+var rates = map[models.Currency]decimal.Decimal{
+	models.USD: decimal.RequireFromString("1"),
+	models.EUR: decimal.RequireFromString("0.85"),
+	models.RUB: decimal.RequireFromString("0.1"),
+}
+
+var fees = models.FeeSchedule{
+	Percent: decimal.RequireFromString("1"),
+	Min:     map[models.Currency]decimal.Decimal{models.USD: decimal.RequireFromString("0.50")},
+	Pairs: map[models.CurrencyPair]models.FeeRule{
+		{From: models.EUR, To: models.USD}: {Fixed: decimal.RequireFromString("2")},
+	},
+}
+
+var limits = models.Limits{
+	Withdraw: map[models.Currency]models.Limit{
+		models.USD: {
+			PerOperation: decimal.RequireFromString("100"),
+			Daily:        decimal.RequireFromString("150"),
+		},
+	},
+	Exchange: map[models.Currency]models.Limit{
+		models.USD: {Monthly: decimal.RequireFromString("50")},
+	},
 }
 
 const apiPrefix = "/api/v1/"
@@ -34,31 +70,77 @@ func setupApp() error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	storage, _, err := app.InitDB(cfg)
+	storage, _, err := app.InitStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	err = services.NewCurrencyService(storage, cfg.CurrenciesRefresh).Load(context.Background())
 	if err != nil {
-		return fmt.Errorf("failed to initialize database: %w", err)
+		return fmt.Errorf("failed to load currencies: %w", err)
 	}
 
 	exchanger := newExchangerClientMock(models.USD, rates)
 
-	jwt, err := services.NewJwtService(services.JWTConfig{
-		SecretKey: cfg.JwtSecret,
-		Lifetime:  cfg.JwtLifetime,
-		Issuer:    cfg.JwtIssuer,
-		Audience:  cfg.JwtAudience,
-	})
+	jwtConfig = services.JWTConfig{
+		Algorithm:        cfg.JwtAlgorithm,
+		Lifetime:         cfg.JwtLifetime,
+		RefreshLifetime:  cfg.RefreshTokenLifetime,
+		KeyRotation:      cfg.JwtKeyRotation,
+		KeyOverlap:       cfg.JwtKeyOverlap,
+		Issuer:           cfg.JwtIssuer,
+		Audience:         cfg.JwtAudience,
+		KeyEncryptionKey: cfg.JwtKeyEncryptionKey,
+	}
+	keyRepository = storage
+
+	jwt, err := services.NewJwtService(jwtConfig, storage)
 	if err != nil {
 		return fmt.Errorf("failed to create jwt service: %w", err)
 	}
 
-	wallet := services.NewWalletService(storage, exchanger, redisMock{})
-	auth := services.NewAuthService(jwt, storage)
+	if err = jwt.RotateKeys(context.Background()); err != nil {
+		return fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	wallet := services.NewWalletService(storage, exchanger, newRedisMock(), services.WalletConfig{
+		QuoteLifetime: cfg.QuoteLifetime,
+	})
+	revocations = newRevocationStoreMock()
+	auth := services.NewAuthService(jwt, storage, revocations)
+	adminService = services.NewAdminService(storage, wallet, auth)
 
 	server = http.NewServer(http.Config{
-		ServiceName:   "",
-		JwtSecret:     cfg.JwtSecret,
-		LaunchSwagger: false,
-	}, wallet, auth)
+		ServiceName:            "",
+		Tokens:                 jwt,
+		LaunchSwagger:          false,
+		IdempotencyKeyLifetime: cfg.IdempotencyKeyLifetime,
+	}, wallet, auth, adminService, newIdempotencyStoreMock(), revocations)
+
+	feeWallet := services.NewWalletService(storage, exchanger, newRedisMock(), services.WalletConfig{
+		QuoteLifetime: cfg.QuoteLifetime,
+		Fees:          fees,
+	})
+
+	feeServer = http.NewServer(http.Config{
+		ServiceName:            "",
+		Tokens:                 jwt,
+		LaunchSwagger:          false,
+		IdempotencyKeyLifetime: cfg.IdempotencyKeyLifetime,
+	}, feeWallet, auth, services.NewAdminService(storage, feeWallet, auth), newIdempotencyStoreMock(), revocations)
+
+	limitWallet := services.NewWalletService(storage, exchanger, newRedisMock(), services.WalletConfig{
+		QuoteLifetime: cfg.QuoteLifetime,
+		Limits:        limits,
+	})
+
+	limitServer = http.NewServer(http.Config{
+		ServiceName:            "",
+		Tokens:                 jwt,
+		LaunchSwagger:          false,
+		IdempotencyKeyLifetime: cfg.IdempotencyKeyLifetime,
+	}, limitWallet, auth, services.NewAdminService(storage, limitWallet, auth), newIdempotencyStoreMock(),
+		revocations)
 	return nil
 }
 
@@ -107,7 +189,26 @@ func upEnvironment() {
 	}
 }
 
+// upSQLite points the wallet at a fresh SQLite file, so that no container is needed.
+func upSQLite() {
+
+	dir, err := os.MkdirTemp("", "wallet-integration")
+	if err != nil {
+		log.Fatalf("could not create directory for SQLite database: %s", err)
+	}
+	sqliteDir = dir
+
+	os.Setenv("DB_DRIVER", string(config.SQLiteDriver))
+	os.Setenv("DB_URL", "file:"+filepath.Join(dir, "wallet.db"))
+	os.Setenv("MIGRATIONS_PATH", "file://migrations/sqlite")
+}
+
 func downEnvironment() {
+	if sqliteDir != "" {
+		os.RemoveAll(sqliteDir)
+		return
+	}
+
 	ctx := context.Background()
 	if err := dbContainer.Terminate(ctx); err != nil {
 		fmt.Printf("Could not terminate PostgreSQL container: %s", err)
@@ -122,7 +223,12 @@ func TestMain(m *testing.M) {
 	}
 	os.Setenv("CONFIG_PATH", "configs/config.env")
 
-	upEnvironment()
+	// the suite runs against Postgres in a container, SQLite is used on request: DB_DRIVER=sqlite go test ./tests/integration/...
+	if os.Getenv("DB_DRIVER") == string(config.SQLiteDriver) {
+		upSQLite()
+	} else {
+		upEnvironment()
+	}
 
 	err = setupApp()
 	if err != nil {
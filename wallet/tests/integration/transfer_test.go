@@ -0,0 +1,207 @@
+package integration
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"sync"
+	"test-task/wallet/internal/domain/models"
+	myhttp "test-task/wallet/internal/transport/http"
+	"testing"
+)
+
+func TestTransfer_Success(t *testing.T) {
+
+	senderToken := getToken(t)
+	recipient, recipientToken := getUserWithToken(t)
+
+	req1 := myhttp.DepositRequest{
+		Amount:   decimal.RequireFromString("100"),
+		Currency: "USD",
+	}
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST",
+		apiPrefix+"wallet/deposit", req1, http.StatusOK, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+senderToken)
+		})
+
+	req2 := myhttp.TransferRequest{
+		Recipient: recipient.Username,
+		Amount:    decimal.RequireFromString("30"),
+		Currency:  "USD",
+	}
+
+	resp := mustSend[myhttp.TransferResponse](t, server, "POST",
+		apiPrefix+"wallet/transfer", req2, http.StatusOK, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+senderToken)
+		})
+
+	assert.Equal(t, "Transfer successful", resp.Message)
+	assertDecimalEqual(t, req1.Amount.Sub(req2.Amount), resp.NewBalance[req1.Currency])
+
+	req2.Recipient = recipient.Email
+
+	_ = mustSend[myhttp.TransferResponse](t, server, "POST",
+		apiPrefix+"wallet/transfer", req2, http.StatusOK, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+senderToken)
+		})
+
+	balance := mustSend[myhttp.BalanceResponse](t, server, "GET",
+		apiPrefix+"balance", nil, http.StatusOK, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+recipientToken)
+		})
+
+	assertDecimalEqual(t, req2.Amount.Mul(decimal.NewFromInt(2)), balance.Balance[req2.Currency])
+}
+
+func TestTransfer_CrossCurrency(t *testing.T) {
+
+	senderToken := getToken(t)
+	recipient, recipientToken := getUserWithToken(t)
+
+	req1 := myhttp.DepositRequest{
+		Amount:   decimal.RequireFromString("100"),
+		Currency: "USD",
+	}
+
+	_ = mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST",
+		apiPrefix+"wallet/deposit", req1, http.StatusOK, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+senderToken)
+		})
+
+	req2 := myhttp.TransferRequest{
+		Recipient:  recipient.Username,
+		Amount:     decimal.RequireFromString("30"),
+		Currency:   "USD",
+		ToCurrency: "EUR",
+	}
+
+	resp := mustSend[myhttp.TransferResponse](t, server, "POST",
+		apiPrefix+"wallet/transfer", req2, http.StatusOK, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+senderToken)
+		})
+
+	creditedAmount := req2.Amount.Mul(rates[models.EUR]).RoundDown(models.EUR.Scale())
+	assertDecimalEqual(t, req1.Amount.Sub(req2.Amount), resp.NewBalance[req2.Currency])
+	assertDecimalEqual(t, creditedAmount, resp.CreditedAmount)
+	assertDecimalEqual(t, rates[models.EUR], resp.Rate)
+
+	balance := mustSend[myhttp.BalanceResponse](t, server, "GET",
+		apiPrefix+"balance", nil, http.StatusOK, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+recipientToken)
+		})
+
+	assertDecimalEqual(t, creditedAmount, balance.Balance[req2.ToCurrency])
+
+	history := mustSend[myhttp.TransactionsResponse](t, server, "GET",
+		apiPrefix+"wallet/transactions", nil, http.StatusOK, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+recipientToken)
+		})
+
+	require.Len(t, history.Transactions, 1)
+	assert.Equal(t, "transfer", history.Transactions[0].Type)
+	require.NotNil(t, history.Transactions[0].Rate)
+	assertDecimalEqual(t, rates[models.EUR], *history.Transactions[0].Rate)
+}
+
+func TestTransfer_InsufficientFunds(t *testing.T) {
+
+	senderToken := getToken(t)
+	recipient, _ := getUserWithToken(t)
+
+	req := myhttp.TransferRequest{
+		Recipient: recipient.Username,
+		Amount:    decimal.RequireFromString("30"),
+		Currency:  "USD",
+	}
+
+	resp := mustSend[myhttp.ErrorResponse](t, server, "POST",
+		apiPrefix+"wallet/transfer", req, http.StatusBadRequest, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+senderToken)
+		})
+
+	assert.Equal(t, "Insufficient funds", resp.Error)
+}
+
+func TestTransfer_UnknownRecipient(t *testing.T) {
+
+	senderToken := getToken(t)
+
+	req := myhttp.TransferRequest{
+		Recipient: "nobody",
+		Amount:    decimal.RequireFromString("30"),
+		Currency:  "USD",
+	}
+
+	resp := mustSend[myhttp.ErrorResponse](t, server, "POST",
+		apiPrefix+"wallet/transfer", req, http.StatusNotFound, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+senderToken)
+		})
+
+	assert.Equal(t, "Recipient not found", resp.Error)
+}
+
+func TestTransfer_ToSelf(t *testing.T) {
+
+	sender, senderToken := getUserWithToken(t)
+
+	req := myhttp.TransferRequest{
+		Recipient: sender.Username,
+		Amount:    decimal.RequireFromString("30"),
+		Currency:  "USD",
+	}
+
+	resp := mustSend[myhttp.ErrorResponse](t, server, "POST",
+		apiPrefix+"wallet/transfer", req, http.StatusBadRequest, func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+senderToken)
+		})
+
+	assert.Equal(t, "Invalid recipient", resp.Error)
+}
+
+func TestTransfer_OppositeDirectionsConcurrently(t *testing.T) {
+
+	user1, token1 := getUserWithToken(t)
+	user2, token2 := getUserWithToken(t)
+	amount := decimal.RequireFromString("1")
+	initial := decimal.RequireFromString("100")
+
+	for _, token := range []string{token1, token2} {
+		_ = mustSend[myhttp.UpdatedBalanceResponse](t, server, "POST",
+			apiPrefix+"wallet/deposit", myhttp.DepositRequest{Amount: initial, Currency: "USD"},
+			http.StatusOK, func(request *http.Request) {
+				request.Header.Set("Authorization", "Bearer "+token)
+			})
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_ = mustSend[myhttp.TransferResponse](t, server, "POST", apiPrefix+"wallet/transfer",
+				myhttp.TransferRequest{Recipient: user2.Username, Amount: amount, Currency: "USD"},
+				http.StatusOK, func(request *http.Request) {
+					request.Header.Set("Authorization", "Bearer "+token1)
+				})
+		}()
+		go func() {
+			defer wg.Done()
+			_ = mustSend[myhttp.TransferResponse](t, server, "POST", apiPrefix+"wallet/transfer",
+				myhttp.TransferRequest{Recipient: user1.Username, Amount: amount, Currency: "USD"},
+				http.StatusOK, func(request *http.Request) {
+					request.Header.Set("Authorization", "Bearer "+token2)
+				})
+		}()
+	}
+	wg.Wait()
+
+	for _, token := range []string{token1, token2} {
+		balance := mustSend[myhttp.BalanceResponse](t, server, "GET",
+			apiPrefix+"balance", nil, http.StatusOK, func(request *http.Request) {
+				request.Header.Set("Authorization", "Bearer "+token)
+			})
+		assertDecimalEqual(t, initial, balance.Balance["USD"])
+	}
+}
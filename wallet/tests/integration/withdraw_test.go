@@ -1,6 +1,7 @@
 package integration
 
 import (
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	myhttp "test-task/wallet/internal/transport/http"
@@ -12,7 +13,7 @@ func TestWithdraw_Success(t *testing.T) {
 	token := getToken(t)
 
 	req1 := myhttp.DepositRequest{
-		Amount:   100,
+		Amount:   decimal.RequireFromString("100"),
 		Currency: "USD",
 	}
 
@@ -22,7 +23,7 @@ func TestWithdraw_Success(t *testing.T) {
 		})
 
 	req2 := myhttp.WithdrawRequest{
-		Amount:   50,
+		Amount:   decimal.RequireFromString("50"),
 		Currency: "USD",
 	}
 
@@ -31,14 +32,14 @@ func TestWithdraw_Success(t *testing.T) {
 			request.Header.Set("Authorization", "Bearer "+token)
 		})
 
-	assert.Equal(t, req1.Amount-req2.Amount, resp.NewBalance[req1.Currency])
+	assertDecimalEqual(t, req1.Amount.Sub(req2.Amount), resp.NewBalance[req1.Currency])
 	assert.Equal(t, "Withdrawal successful", resp.Message)
 }
 
 func TestWithdraw_MissingToken(t *testing.T) {
 
 	req := myhttp.WithdrawRequest{
-		Amount:   100.5,
+		Amount:   decimal.RequireFromString("100.5"),
 		Currency: "USD",
 	}
 
@@ -50,7 +51,7 @@ func TestWithdraw_NegativeAmount(t *testing.T) {
 
 	token := getToken(t)
 	req := myhttp.WithdrawRequest{
-		Amount:   -100.5,
+		Amount:   decimal.RequireFromString("-100.5"),
 		Currency: "USD",
 	}
 
@@ -66,7 +67,7 @@ func TestWithdraw_InvalidCurrency(t *testing.T) {
 
 	token := getToken(t)
 	req := myhttp.WithdrawRequest{
-		Amount:   100.5,
+		Amount:   decimal.RequireFromString("100.5"),
 		Currency: "tugrik",
 	}
 
@@ -83,7 +84,7 @@ func TestWithdraw_InsufficientFunds(t *testing.T) {
 	token := getToken(t)
 
 	req1 := myhttp.DepositRequest{
-		Amount:   100,
+		Amount:   decimal.RequireFromString("100"),
 		Currency: "USD",
 	}
 
@@ -93,7 +94,7 @@ func TestWithdraw_InsufficientFunds(t *testing.T) {
 		})
 
 	req2 := myhttp.WithdrawRequest{
-		Amount:   150,
+		Amount:   decimal.RequireFromString("150"),
 		Currency: "USD",
 	}
 
@@ -109,7 +110,7 @@ func TestWithdraw_InsufficientFunds(t *testing.T) {
 			request.Header.Set("Authorization", "Bearer "+token)
 		})
 
-	assert.Equal(t, req1.Amount, balance.Balance[req1.Currency])
+	assertDecimalEqual(t, req1.Amount, balance.Balance[req1.Currency])
 }
 
 func TestWithdraw_NoAccountInThisCurrency(t *testing.T) {
@@ -117,7 +118,7 @@ func TestWithdraw_NoAccountInThisCurrency(t *testing.T) {
 	token := getToken(t)
 
 	req2 := myhttp.WithdrawRequest{
-		Amount:   50,
+		Amount:   decimal.RequireFromString("50"),
 		Currency: "USD",
 	}
 